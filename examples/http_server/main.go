@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+func main() {
+	logger := server.NewLogger("Main")
+	logger.ErrOut = os.Stderr
+	logger.Out = os.Stderr
+	logger.Info("Initializing server!")
+
+	transport := server.NewStreamableHTTPTransport()
+	defer transport.Stop()
+
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "get_weather",
+		Description: "Get the current weather of a location",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"location": map[string]interface{}{
+					"type":        "string",
+					"description": "City name or state name",
+				},
+			},
+			"required": []string{"location"},
+		},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		responseText := fmt.Sprintf("Current weather in %v is 27 degree Celsius", arguments["location"])
+		return server.ToolResult{
+			Content: []server.ToolCallContent{
+				{
+					Type: "text",
+					Text: &responseText,
+				},
+			},
+			IsError: false,
+		}
+	})
+
+	s := server.NewDefaultServer(transport, server.ProtocolVersion20250326, "1.0.0", "HTTPMCPServer")
+	s = server.WithToolsCapability(s, false, false)
+	s = server.WithToolManager(s, &toolManager)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", transport)
+	httpServer := &http.Server{
+		Addr:    "127.0.0.1:8080",
+		Handler: mux,
+	}
+
+	go func() {
+		if err := s.Start(ctx); err != nil {
+			logger.Error("Server error: %v", err)
+		}
+	}()
+
+	go func() {
+		if err := transport.Start(ctx); err != nil {
+			logger.Error("Transport error: %v", err)
+		}
+	}()
+
+	httpErrCh := make(chan error, 1)
+	go func() {
+		logger.Info("Listening on http://%s/mcp", httpServer.Addr)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			httpErrCh <- err
+		}
+		close(httpErrCh)
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case <-sigChan:
+		logger.Info("Received termination signal")
+	case err := <-httpErrCh:
+		if err != nil {
+			logger.Error("HTTP server terminated due to error: %v", err)
+		}
+	}
+
+	s.Close()
+	cancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+
+	logger.Info("Gracefully shutting down...")
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("HTTP server shutdown error: %v", err)
+	}
+
+	logger.Info("Exiting server")
+}
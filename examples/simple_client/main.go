@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/client"
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+func main() {
+	logger := server.NewLogger("Main")
+	logger.ErrOut = os.Stderr
+	logger.Out = os.Stderr
+
+	// Run build_examples.sh first so that the server binary exists
+	serverPath := "build/simple_server"
+	if len(os.Args) > 1 {
+		serverPath = os.Args[1]
+	}
+
+	transport, err := client.NewCommandTransport(serverPath)
+	if err != nil {
+		logger.Fatal("Failed to create transport: %v", err)
+	}
+	defer transport.Stop()
+
+	c := client.NewClient(transport, server.ProtocolVersion20241105, "1.0.0", "SimpleMCPClient")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	go func() {
+		if err := transport.Start(ctx); err != nil {
+			logger.Error("Transport error: %v", err)
+		}
+	}()
+	go func() {
+		if err := c.Start(ctx); err != nil {
+			logger.Error("Client error: %v", err)
+		}
+	}()
+
+	initializeResult, err := c.Initialize(ctx)
+	if err != nil {
+		logger.Error("Initialize failed: %v", err)
+		return
+	}
+	logger.Info("Connected to %s %s", initializeResult.ServerInfo.Name, initializeResult.ServerInfo.Version)
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		logger.Error("List tools failed: %v", err)
+		return
+	}
+	for _, tool := range tools {
+		logger.Info("Tool: %s - %s", tool.Name, tool.Description)
+	}
+
+	result, err := c.CallTool(ctx, "get_weather", map[string]interface{}{"location": "Jakarta"})
+	if err != nil {
+		logger.Error("Call tool failed: %v", err)
+		return
+	}
+	for _, content := range result.Content {
+		if content.Text != nil {
+			logger.Info("Result: %s", *content.Text)
+		}
+	}
+
+	c.Close()
+}
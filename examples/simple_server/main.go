@@ -47,10 +47,32 @@ func main() {
 		}
 	})
 
+	type convertInput struct {
+		Value float64 `json:"value" required:"true" description:"Temperature to convert"`
+		Unit  string  `json:"unit" enum:"celsius,fahrenheit" default:"celsius" description:"Unit of the given temperature"`
+	}
+	type convertOutput struct {
+		Value float64 `json:"value"`
+		Unit  string  `json:"unit"`
+	}
+	err := server.AddTypedTool(&toolManager, "convert_temperature", "Convert a temperature between Celsius and Fahrenheit",
+		func(ctx context.Context, input convertInput) (convertOutput, error) {
+			if input.Unit == "fahrenheit" {
+				return convertOutput{Value: (input.Value - 32) * 5 / 9, Unit: "celsius"}, nil
+			}
+			return convertOutput{Value: input.Value*9/5 + 32, Unit: "fahrenheit"}, nil
+		})
+	if err != nil {
+		logger.Error("Failed to add tool: %v", err)
+		os.Exit(1)
+	}
+
 	config := server.ServerConfig{
 		LogLevel:                      server.LogDebug,
 		ShowTimestamps:                true,
 		MaxRequestActive:              10,
+		MaxRequestQueued:              100,
+		RequestQueuePolicy:            server.QueuePolicyWait,
 		LogFile:                       "",
 		OutgoingMessageTimeoutSeconds: 5,
 	}
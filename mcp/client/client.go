@@ -0,0 +1,416 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+var (
+	ErrClientClosed   = errors.New("client closed")
+	ErrNotInitialized = errors.New("client not initialized")
+)
+
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type InitializeResult struct {
+	ProtocolVersion string              `json:"protocolVersion"`
+	Capabilities    server.Capabilities `json:"capabilities"`
+	ServerInfo      Implementation      `json:"serverInfo"`
+	Instructions    string              `json:"instructions,omitempty"`
+}
+
+type ListToolsResult struct {
+	Tools      []server.Tool `json:"tools"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
+
+type CallToolResult struct {
+	Content           []server.ToolCallContent `json:"content"`
+	StructuredContent map[string]interface{}   `json:"structuredContent,omitempty"`
+	IsError           bool                     `json:"isError"`
+}
+
+type RequestHandler func(context.Context, messages.Request) (*messages.JsonRPCResult, *messages.ErrorResponse)
+type NotificationHandler func(messages.JsonRPCMessage)
+
+type Client struct {
+	Name                 string
+	Version              string
+	ProtocolVersion      string
+	Capabilities         messages.ClientCapabilities
+	transport            Transport
+	logger               *server.Logger
+	config               ClientConfig
+	nextID               atomic.Int64
+	pendingRequests      map[interface{}]chan messages.JsonRPCMessage
+	pendingMutex         sync.Mutex
+	requestHandlers      map[string]RequestHandler
+	notificationHandlers map[string]NotificationHandler
+	handlersMutex        sync.RWMutex
+	initializeResult     *InitializeResult
+	stateMutex           sync.RWMutex
+	closeSignalChan      chan int
+	closeOnce            sync.Once
+}
+
+func decodeResult(result *messages.JsonRPCResult, target interface{}) error {
+	if result == nil {
+		return errors.New("response has no result")
+	}
+
+	marshaled, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	if err := json.Unmarshal(marshaled, target); err != nil {
+		return fmt.Errorf("failed to decode result: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) SetRequestHandler(method string, handler RequestHandler) {
+	c.handlersMutex.Lock()
+	defer c.handlersMutex.Unlock()
+
+	c.requestHandlers[method] = handler
+}
+
+func (c *Client) SetNotificationHandler(method string, handler NotificationHandler) {
+	c.handlersMutex.Lock()
+	defer c.handlersMutex.Unlock()
+
+	c.notificationHandlers[method] = handler
+}
+
+func (c *Client) write(msg messages.JsonRPCMessage, ctx context.Context) error {
+	withTimeoutCtx, cancel := context.WithTimeout(ctx, c.config.RequestTimeoutSeconds*time.Second)
+	defer cancel()
+
+	return c.transport.Write(msg, withTimeoutCtx)
+}
+
+func (c *Client) send(ctx context.Context, request *messages.Request) (*messages.JsonRPCResult, error) {
+	select {
+	case <-c.closeSignalChan:
+		return nil, ErrClientClosed
+	default:
+	}
+
+	responseCh := make(chan messages.JsonRPCMessage, 1)
+	key := messages.NormalizeID(request.ID)
+	c.pendingMutex.Lock()
+	c.pendingRequests[key] = responseCh
+	c.pendingMutex.Unlock()
+
+	defer func() {
+		c.pendingMutex.Lock()
+		delete(c.pendingRequests, key)
+		c.pendingMutex.Unlock()
+	}()
+
+	c.logger.Debug("Sending request: %s (ID: %v)", request.Method, request.ID)
+	if err := c.write(*request.ToJsonRPCMessage(), ctx); err != nil {
+		return nil, fmt.Errorf("failed to send request %s: %w", request.Method, err)
+	}
+
+	withTimeoutCtx, cancel := context.WithTimeout(ctx, c.config.RequestTimeoutSeconds*time.Second)
+	defer cancel()
+
+	select {
+	case response := <-responseCh:
+		if response.Error != nil {
+			return nil, response.Error
+		}
+		if response.Result == nil {
+			return &messages.JsonRPCResult{}, nil
+		}
+		return response.Result, nil
+	case <-withTimeoutCtx.Done():
+		reason := "request cancelled"
+		if errors.Is(withTimeoutCtx.Err(), context.DeadlineExceeded) {
+			reason = "request timed out"
+		}
+		cancellation := messages.NewCancellationOfRequest(request, reason)
+		if err := c.write(*cancellation.ToJsonRPCMessage(), context.Background()); err != nil {
+			c.logger.Warn("Failed to send cancellation for request ID %v: %v", request.ID, err)
+		}
+		return nil, fmt.Errorf("request %s failed: %w", request.Method, withTimeoutCtx.Err())
+	case <-c.closeSignalChan:
+		return nil, ErrClientClosed
+	}
+}
+
+// SendRequest issues an arbitrary request and waits for its result
+func (c *Client) SendRequest(ctx context.Context, method string, params *messages.JsonRPCParams) (*messages.JsonRPCResult, error) {
+	request := &messages.Request{
+		JsonRPC: "2.0",
+		ID:      c.nextID.Add(1),
+		Method:  method,
+		Params:  params,
+	}
+	return c.send(ctx, request)
+}
+
+func (c *Client) Notify(ctx context.Context, method string, params *messages.JsonRPCParams) error {
+	notification := messages.NewJsonRPCMessage()
+	notification.Method = &method
+	notification.Params = params
+
+	c.logger.Debug("Sending notification: %s", method)
+	return c.write(*notification, ctx)
+}
+
+func (c *Client) Initialize(ctx context.Context) (*InitializeResult, error) {
+	params := messages.JsonRPCParams{
+		"protocolVersion": c.ProtocolVersion,
+		"capabilities":    c.Capabilities,
+		"clientInfo": Implementation{
+			Name:    c.Name,
+			Version: c.Version,
+		},
+	}
+
+	result, err := c.SendRequest(ctx, "initialize", &params)
+	if err != nil {
+		return nil, err
+	}
+
+	var initializeResult InitializeResult
+	if err := decodeResult(result, &initializeResult); err != nil {
+		return nil, err
+	}
+
+	if initializeResult.ProtocolVersion != c.ProtocolVersion && !server.IsKnownProtocolVersion(initializeResult.ProtocolVersion) {
+		return nil, fmt.Errorf("unsupported protocol version from server: %s", initializeResult.ProtocolVersion)
+	}
+
+	if err := c.Notify(ctx, "notifications/initialized", nil); err != nil {
+		return nil, fmt.Errorf("failed to send initialized notification: %w", err)
+	}
+
+	c.stateMutex.Lock()
+	c.initializeResult = &initializeResult
+	c.stateMutex.Unlock()
+
+	c.logger.Info("Initialized with %s %s using protocol %s", initializeResult.ServerInfo.Name, initializeResult.ServerInfo.Version, initializeResult.ProtocolVersion)
+	return &initializeResult, nil
+}
+
+// InitializeResult returns the handshake result, or nil before Initialize succeeded
+func (c *Client) InitializeResult() *InitializeResult {
+	c.stateMutex.RLock()
+	defer c.stateMutex.RUnlock()
+
+	return c.initializeResult
+}
+
+func (c *Client) ensureInitialized() error {
+	if c.InitializeResult() == nil {
+		return ErrNotInitialized
+	}
+	return nil
+}
+
+func (c *Client) ListTools(ctx context.Context) ([]server.Tool, error) {
+	if err := c.ensureInitialized(); err != nil {
+		return nil, err
+	}
+
+	tools := make([]server.Tool, 0)
+	cursor := ""
+	for {
+		var params *messages.JsonRPCParams
+		if cursor != "" {
+			params = &messages.JsonRPCParams{"cursor": cursor}
+		}
+
+		result, err := c.SendRequest(ctx, "tools/list", params)
+		if err != nil {
+			return nil, err
+		}
+
+		var page ListToolsResult
+		if err := decodeResult(result, &page); err != nil {
+			return nil, err
+		}
+		tools = append(tools, page.Tools...)
+
+		if page.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func (c *Client) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*CallToolResult, error) {
+	if err := c.ensureInitialized(); err != nil {
+		return nil, err
+	}
+
+	if arguments == nil {
+		arguments = make(map[string]interface{})
+	}
+	params := messages.JsonRPCParams{
+		"name":      name,
+		"arguments": arguments,
+	}
+
+	result, err := c.SendRequest(ctx, "tools/call", &params)
+	if err != nil {
+		return nil, err
+	}
+
+	var callResult CallToolResult
+	if err := decodeResult(result, &callResult); err != nil {
+		return nil, err
+	}
+	return &callResult, nil
+}
+
+func (c *Client) Ping(ctx context.Context) error {
+	ping := messages.NewPingRequest(c.nextID.Add(1))
+	request := messages.NewRequestFromJsonRPCMessage(*ping.ToJsonRPCMessage())
+	_, err := c.send(ctx, &request)
+	return err
+}
+
+func (c *Client) handleRequest(ctx context.Context, request messages.Request) {
+	response := messages.NewJsonRPCMessage()
+	response.ID = request.ID
+
+	c.handlersMutex.RLock()
+	handler, exist := c.requestHandlers[request.Method]
+	c.handlersMutex.RUnlock()
+
+	if !exist {
+		response.Error = &messages.ErrorResponse{
+			Code:    messages.JsonRPCErrorMethodNotFound,
+			Message: "Method not found",
+		}
+	} else {
+		result, errResponse := handler(ctx, request)
+		if errResponse != nil {
+			response.Error = errResponse
+		} else {
+			if result == nil {
+				result = &messages.JsonRPCResult{}
+			}
+			response.Result = result
+		}
+	}
+
+	if err := c.write(*response, ctx); err != nil {
+		c.logger.Error("Failed to write response: %v", err)
+	}
+}
+
+func (c *Client) handleMessageFromTransport(ctx context.Context, msg *messages.JsonRPCMessage) {
+	if msg.IsResponse() {
+		// Objects and arrays can't be map keys, and the client never issues such IDs anyway
+		if !messages.IsValidID(msg.ID) {
+			c.logger.Warn("Dropping response with invalid ID type %T", msg.ID)
+			return
+		}
+
+		key := messages.NormalizeID(msg.ID)
+		c.pendingMutex.Lock()
+		responseCh, exist := c.pendingRequests[key]
+		delete(c.pendingRequests, key)
+		c.pendingMutex.Unlock()
+
+		if !exist {
+			c.logger.Warn("Received response for unknown request ID: %v", msg.ID)
+			return
+		}
+		responseCh <- *msg
+	} else if msg.IsRequest() {
+		request := messages.NewRequestFromJsonRPCMessage(*msg)
+		c.logger.Debug("Handling request: %s (ID: %v)", request.Method, request.ID)
+		go c.handleRequest(ctx, request)
+	} else if msg.IsNotification() {
+		c.handlersMutex.RLock()
+		handler, exist := c.notificationHandlers[*msg.Method]
+		c.handlersMutex.RUnlock()
+
+		if exist {
+			go handler(*msg)
+		} else {
+			c.logger.Debug("Unhandled notification: %s", *msg.Method)
+		}
+	} else {
+		c.logger.Warn("Received invalid message type")
+	}
+}
+
+func (c *Client) Start(ctx context.Context) error {
+	c.logger.Info("Client started")
+	defer c.logger.Info("Client stopping")
+
+	for {
+		select {
+		case <-c.closeSignalChan:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("client stopped: %w", ctx.Err())
+		case msg, ok := <-c.transport.Read():
+			if !ok {
+				c.logger.Info("Transport channel closed")
+				c.Close()
+				return nil
+			}
+
+			c.handleMessageFromTransport(ctx, &msg)
+		}
+	}
+}
+
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closeSignalChan)
+	})
+	return nil
+}
+
+func NewClient(transport Transport, protocolVersion string, version string, name string) *Client {
+	return NewClientWithConfig(transport, protocolVersion, version, name, NewDefaultConfig())
+}
+
+func NewClientWithConfig(transport Transport, protocolVersion string, version string, name string, config ClientConfig) *Client {
+	logger := server.NewLogger(name)
+	logger.MinLevel = config.LogLevel
+	logger.ShowTime = config.ShowTimestamps
+	logger.Out = os.Stderr
+	logger.ErrOut = os.Stderr
+
+	client := &Client{
+		Name:                 name,
+		Version:              version,
+		ProtocolVersion:      protocolVersion,
+		transport:            transport,
+		logger:               logger,
+		config:               config,
+		pendingRequests:      make(map[interface{}]chan messages.JsonRPCMessage),
+		requestHandlers:      make(map[string]RequestHandler),
+		notificationHandlers: make(map[string]NotificationHandler),
+		closeSignalChan:      make(chan int, 1),
+	}
+
+	client.requestHandlers["ping"] = func(ctx context.Context, request messages.Request) (*messages.JsonRPCResult, *messages.ErrorResponse) {
+		return &messages.JsonRPCResult{}, nil
+	}
+
+	return client
+}
@@ -0,0 +1,206 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/client"
+	"github.com/alwint3r/mcp2go/mcp/messages"
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+// pipeTransport connects a client and a server in memory
+type pipeTransport struct {
+	in  chan messages.JsonRPCMessage
+	out chan messages.JsonRPCMessage
+}
+
+func newPipeTransports() (*pipeTransport, *pipeTransport) {
+	clientToServer := make(chan messages.JsonRPCMessage, 10)
+	serverToClient := make(chan messages.JsonRPCMessage, 10)
+	return &pipeTransport{in: serverToClient, out: clientToServer}, &pipeTransport{in: clientToServer, out: serverToClient}
+}
+
+func (p *pipeTransport) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (p *pipeTransport) Read() <-chan messages.JsonRPCMessage {
+	return p.in
+}
+
+func (p *pipeTransport) Write(msg messages.JsonRPCMessage, ctx context.Context) error {
+	select {
+	case p.out <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *pipeTransport) Stop() error {
+	return nil
+}
+
+func newTestClient(t *testing.T) *client.Client {
+	t.Helper()
+
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "greet",
+		Description: "Greet someone",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		text := "Hello, " + arguments["name"].(string)
+		return server.ToolResult{
+			Content: []server.ToolCallContent{{Type: "text", Text: &text}},
+		}
+	})
+	return newTestClientWithTools(t, server.ProtocolVersion20250326, &toolManager)
+}
+
+func newTestClientWithTools(t *testing.T, protocolVersion string, toolManager *server.ToolManager) *client.Client {
+	t.Helper()
+
+	clientTransport, serverTransport := newPipeTransports()
+
+	serverConfig := server.NewDefaultConfig()
+	serverConfig.LogLevel = server.LogError
+	s := server.NewDefaultServerWithConfig(serverTransport, protocolVersion, "1.0.0", "TestServer", serverConfig)
+	s = server.WithToolsCapability(s, false, false)
+	s = server.WithToolManager(s, toolManager)
+
+	clientConfig := client.NewDefaultConfig()
+	clientConfig.LogLevel = server.LogError
+	c := client.NewClientWithConfig(clientTransport, protocolVersion, "1.0.0", "TestClient", clientConfig)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Start(ctx)
+	go c.Start(ctx)
+
+	t.Cleanup(func() {
+		c.Close()
+		s.Close()
+		cancel()
+	})
+	return c
+}
+
+func TestClientRequiresInitialize(t *testing.T) {
+	c := newTestClient(t)
+
+	if _, err := c.ListTools(context.Background()); !errors.Is(err, client.ErrNotInitialized) {
+		t.Errorf("expected ErrNotInitialized, got %v", err)
+	}
+}
+
+func TestClientStructuredContent(t *testing.T) {
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "forecast",
+		InputSchema: map[string]interface{}{"type": "object"},
+		OutputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"temperature": map[string]interface{}{"type": "number"}},
+		},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		return server.ToolResult{StructuredContent: map[string]interface{}{"temperature": 21.5}}
+	})
+	c := newTestClientWithTools(t, server.ProtocolVersion20250618, &toolManager)
+	ctx := context.Background()
+
+	initializeResult, err := c.Initialize(ctx)
+	if err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	if initializeResult.ProtocolVersion != server.ProtocolVersion20250618 {
+		t.Errorf("expected protocol %s, got %s", server.ProtocolVersion20250618, initializeResult.ProtocolVersion)
+	}
+
+	result, err := c.CallTool(ctx, "forecast", nil)
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if result.StructuredContent["temperature"] != 21.5 {
+		t.Errorf("expected structured content, got %+v", result)
+	}
+}
+
+func TestClientToolRoundTrip(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	initializeResult, err := c.Initialize(ctx)
+	if err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	if initializeResult.ServerInfo.Name != "TestServer" {
+		t.Errorf("server name should be TestServer, got %s", initializeResult.ServerInfo.Name)
+	}
+	if initializeResult.Capabilities.Tools == nil {
+		t.Errorf("server should advertise the tools capability")
+	}
+
+	if err := c.Ping(ctx); err != nil {
+		t.Errorf("ping failed: %v", err)
+	}
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("list tools failed: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "greet" {
+		t.Errorf("expected the greet tool, got %+v", tools)
+	}
+
+	result, err := c.CallTool(ctx, "greet", map[string]interface{}{"name": "Gopher"})
+	if err != nil {
+		t.Fatalf("call tool failed: %v", err)
+	}
+	if result.IsError || len(result.Content) != 1 || *result.Content[0].Text != "Hello, Gopher" {
+		t.Errorf("unexpected tool result: %+v", result)
+	}
+
+	_, err = c.SendRequest(ctx, "unknown/method", nil)
+	var errResponse *messages.ErrorResponse
+	if !errors.As(err, &errResponse) || errResponse.Code != messages.JsonRPCErrorMethodNotFound {
+		t.Errorf("expected method not found error, got %v", err)
+	}
+}
+
+func TestClientResponseWithInvalidID(t *testing.T) {
+	clientTransport, peer := newPipeTransports()
+	clientConfig := client.NewDefaultConfig()
+	clientConfig.LogLevel = server.LogError
+	c := client.NewClientWithConfig(clientTransport, server.ProtocolVersion20250326, "1.0.0", "TestClient", clientConfig)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Start(ctx)
+	defer c.Close()
+
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","id":{},"result":{}}`,
+		`{"jsonrpc":"2.0","id":[],"result":{}}`,
+		`{"jsonrpc":"2.0","id":"alive","method":"ping"}`,
+	} {
+		var msg messages.JsonRPCMessage
+		if err := json.Unmarshal([]byte(body), &msg); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		peer.Write(msg, ctx)
+	}
+
+	select {
+	case response := <-peer.Read():
+		if response.ID != "alive" || response.Error != nil {
+			t.Errorf("expected ping response after invalid IDs, got %+v", response)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("client stopped answering after a response with an invalid ID")
+	}
+}
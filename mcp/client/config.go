@@ -0,0 +1,21 @@
+package client
+
+import (
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+type ClientConfig struct {
+	LogLevel              server.LogLevel `json:"logLevel"`
+	ShowTimestamps        bool            `json:"showTimestamps"`
+	RequestTimeoutSeconds time.Duration   `json:"requestTimeoutSeconds"`
+}
+
+func NewDefaultConfig() ClientConfig {
+	return ClientConfig{
+		LogLevel:              server.LogInfo,
+		ShowTimestamps:        true,
+		RequestTimeoutSeconds: 30,
+	}
+}
@@ -0,0 +1,246 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+type CommandConfig struct {
+	Dir string
+	// Env is appended to the environment of the current process
+	Env []string
+	// CloseTimeoutSeconds is how long to wait for the process to exit after stdin is closed
+	CloseTimeoutSeconds time.Duration
+	// TerminateTimeoutSeconds is how long to wait after SIGTERM before the process is killed
+	TerminateTimeoutSeconds time.Duration
+}
+
+// outputWaitDelay bounds how long Wait keeps copying output after the process exited, children may hold stdout open forever
+const outputWaitDelay = 2 * time.Second
+
+func NewDefaultCommandConfig() CommandConfig {
+	return CommandConfig{
+		Dir:                     "",
+		Env:                     []string{},
+		CloseTimeoutSeconds:     5,
+		TerminateTimeoutSeconds: 5,
+	}
+}
+
+// lineLogger forwards every complete line written to it as a log record
+type lineLogger struct {
+	logger *server.Logger
+	buffer bytes.Buffer
+	mutex  sync.Mutex
+}
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.buffer.Write(p)
+	for {
+		line, err := l.buffer.ReadString('\n')
+		if err != nil {
+			// Keep the incomplete line for the next write
+			l.buffer.WriteString(line)
+			break
+		}
+		l.logger.Info("%s", line[:len(line)-1])
+	}
+	return len(p), nil
+}
+
+func (l *lineLogger) flush() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.buffer.Len() > 0 {
+		l.logger.Info("%s", l.buffer.String())
+		l.buffer.Reset()
+	}
+}
+
+// CommandTransport launches a server binary and talks to it over its stdin and stdout
+type CommandTransport struct {
+	stream       *StreamTransport
+	cmd          *exec.Cmd
+	stdin        io.WriteCloser
+	stdoutReader *io.PipeReader
+	stdoutWriter *io.PipeWriter
+	stderr       *lineLogger
+	logger       *server.Logger
+	config       CommandConfig
+	started      bool
+	stopped      bool
+	startMutex   sync.Mutex
+	exited       chan struct{}
+	waitErr      error
+	stopOnce     sync.Once
+	stopErr      error
+}
+
+func NewCommandTransport(command string, args ...string) (*CommandTransport, error) {
+	return NewCommandTransportWithConfig(NewDefaultCommandConfig(), command, args...)
+}
+
+func NewCommandTransportWithConfig(config CommandConfig, command string, args ...string) (*CommandTransport, error) {
+	if config.CloseTimeoutSeconds <= 0 {
+		config.CloseTimeoutSeconds = NewDefaultCommandConfig().CloseTimeoutSeconds
+	}
+	if config.TerminateTimeoutSeconds <= 0 {
+		config.TerminateTimeoutSeconds = NewDefaultCommandConfig().TerminateTimeoutSeconds
+	}
+
+	cmd := exec.Command(command, args...)
+	cmd.Dir = config.Dir
+	if len(config.Env) > 0 {
+		cmd.Env = append(os.Environ(), config.Env...)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+
+	stdoutReader, stdoutWriter := io.Pipe()
+	cmd.Stdout = stdoutWriter
+	cmd.WaitDelay = outputWaitDelay
+
+	stderrLogger := server.NewLogger(filepath.Base(command))
+	stderrLogger.Out = os.Stderr
+	stderrLogger.ErrOut = os.Stderr
+	stderrLogger.ShowTime = false
+	stderr := &lineLogger{logger: stderrLogger}
+	cmd.Stderr = stderr
+
+	logger := server.NewLogger("CommandTransport")
+	logger.Out = os.Stderr
+	logger.ErrOut = os.Stderr
+
+	return &CommandTransport{
+		stream:       NewStreamTransport(stdoutReader, stdin),
+		cmd:          cmd,
+		stdin:        stdin,
+		stdoutReader: stdoutReader,
+		stdoutWriter: stdoutWriter,
+		stderr:       stderr,
+		logger:       logger,
+		config:       config,
+		exited:       make(chan struct{}),
+	}, nil
+}
+
+func (t *CommandTransport) Read() <-chan messages.JsonRPCMessage {
+	return t.stream.Read()
+}
+
+func (t *CommandTransport) Write(msg messages.JsonRPCMessage, ctx context.Context) error {
+	return t.stream.Write(msg, ctx)
+}
+
+func (t *CommandTransport) Start(ctx context.Context) error {
+	t.startMutex.Lock()
+	if t.started {
+		t.startMutex.Unlock()
+		return errors.New("command transport already started")
+	}
+	if t.stopped {
+		t.startMutex.Unlock()
+		close(t.stream.readerChannel)
+		return errors.New("command transport already stopped")
+	}
+	if err := t.cmd.Start(); err != nil {
+		t.startMutex.Unlock()
+		close(t.stream.readerChannel)
+		return fmt.Errorf("failed to start command: %w", err)
+	}
+	t.started = true
+	t.startMutex.Unlock()
+
+	t.logger.Info("Started %s (pid %d)", t.cmd.Path, t.cmd.Process.Pid)
+
+	go func() {
+		t.waitErr = t.cmd.Wait()
+		t.stderr.flush()
+		t.stdoutWriter.Close()
+		if t.waitErr != nil {
+			t.logger.Warn("Process exited: %v", t.waitErr)
+		} else {
+			t.logger.Info("Process exited")
+		}
+		close(t.exited)
+	}()
+
+	return t.stream.Start(ctx)
+}
+
+// Stop closes stdin and escalates to SIGTERM and then SIGKILL if the process does not exit in time
+func (t *CommandTransport) Stop() error {
+	t.stopOnce.Do(func() {
+		t.startMutex.Lock()
+		started := t.started
+		t.stopped = true
+		t.startMutex.Unlock()
+
+		t.stdin.Close()
+		if !started {
+			return
+		}
+
+		select {
+		case <-t.exited:
+			return
+		case <-time.After(t.config.CloseTimeoutSeconds * time.Second):
+		}
+
+		t.logger.Warn("Process did not exit after stdin was closed, sending SIGTERM")
+		if err := t.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+			t.logger.Warn("Failed to send SIGTERM: %v", err)
+		}
+
+		select {
+		case <-t.exited:
+			return
+		case <-time.After(t.config.TerminateTimeoutSeconds * time.Second):
+		}
+
+		t.logger.Warn("Process did not exit after SIGTERM, sending SIGKILL")
+		// The process may be gone already while Wait still drains its output
+		if err := t.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			t.stopErr = fmt.Errorf("failed to kill process: %w", err)
+			return
+		}
+		// Nobody may be reading stdout anymore, a blocked copy would keep Wait from returning
+		t.stdoutReader.Close()
+		<-t.exited
+	})
+	return t.stopErr
+}
+
+// Exited is closed once the process has exited
+func (t *CommandTransport) Exited() <-chan struct{} {
+	return t.exited
+}
+
+// ExitError returns the result of waiting for the process, it is only meaningful after Exited is closed
+func (t *CommandTransport) ExitError() error {
+	select {
+	case <-t.exited:
+		return t.waitErr
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,162 @@
+package client_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/client"
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+const helperProcessEnv = "MCP2GO_HELPER_PROCESS=1"
+
+// TestHelperServerProcess is not a real test, it runs a stdio server when launched by the command transport tests
+func TestHelperServerProcess(t *testing.T) {
+	if os.Getenv("MCP2GO_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	transport := server.NewStdioTransport()
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "pid",
+		Description: "Report the server process",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		text := "helper"
+		return server.ToolResult{
+			Content: []server.ToolCallContent{{Type: "text", Text: &text}},
+		}
+	})
+
+	s := server.NewDefaultServer(transport, server.ProtocolVersion20250326, "1.0.0", "HelperServer")
+	s = server.WithToolsCapability(s, false, false)
+	s = server.WithToolManager(s, &toolManager)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Start(ctx)
+
+	transport.Start(ctx)
+	os.Exit(0)
+}
+
+func TestCommandTransportEndToEnd(t *testing.T) {
+	config := client.NewDefaultCommandConfig()
+	config.Env = []string{helperProcessEnv}
+	transport, err := client.NewCommandTransportWithConfig(config, os.Args[0], "-test.run=TestHelperServerProcess")
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+
+	clientConfig := client.NewDefaultConfig()
+	clientConfig.LogLevel = server.LogError
+	c := client.NewClientWithConfig(transport, server.ProtocolVersion20250326, "1.0.0", "TestClient", clientConfig)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go transport.Start(ctx)
+	go c.Start(ctx)
+
+	if _, err := c.Initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+
+	result, err := c.CallTool(ctx, "pid", nil)
+	if err != nil {
+		t.Fatalf("call tool failed: %v", err)
+	}
+	if *result.Content[0].Text != "helper" {
+		t.Errorf("unexpected tool result: %+v", result)
+	}
+
+	if err := transport.Stop(); err != nil {
+		t.Errorf("stop failed: %v", err)
+	}
+	select {
+	case <-transport.Exited():
+	default:
+		t.Errorf("process should have exited after stop")
+	}
+	if err := transport.ExitError(); err != nil {
+		t.Errorf("process should exit cleanly once stdin is closed, got %v", err)
+	}
+}
+
+func TestCommandTransportTerminatesStuckProcess(t *testing.T) {
+	config := client.NewDefaultCommandConfig()
+	config.CloseTimeoutSeconds = 1
+	config.TerminateTimeoutSeconds = 1
+	transport, err := client.NewCommandTransportWithConfig(config, "sleep", "30")
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go transport.Start(ctx)
+
+	// Give the process a moment to start
+	time.Sleep(100 * time.Millisecond)
+
+	started := time.Now()
+	if err := transport.Stop(); err != nil {
+		t.Errorf("stop failed: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Errorf("stop should escalate to SIGTERM, took %v", elapsed)
+	}
+	if transport.ExitError() == nil {
+		t.Errorf("terminated process should report an exit error")
+	}
+}
+
+func TestCommandTransportStopsWhenChildHoldsStdout(t *testing.T) {
+	config := client.NewDefaultCommandConfig()
+	config.CloseTimeoutSeconds = 1
+	config.TerminateTimeoutSeconds = 1
+	// The background sleep inherits stdout and outlives the terminated process
+	transport, err := client.NewCommandTransportWithConfig(config, "sh", "-c", "sleep 20 & exec sleep 20")
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go transport.Start(ctx)
+
+	// Give the process a moment to start
+	time.Sleep(100 * time.Millisecond)
+
+	started := time.Now()
+	if err := transport.Stop(); err != nil {
+		t.Errorf("stop failed: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Errorf("stop should not wait for children holding stdout, took %v", elapsed)
+	}
+}
+
+func TestCommandTransportDefaultsZeroTimeouts(t *testing.T) {
+	// The process exits on its own a moment after stdin closes, a zero timeout must not terminate it first
+	transport, err := client.NewCommandTransportWithConfig(client.CommandConfig{}, "sh", "-c", "read line; sleep 0.5")
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go transport.Start(ctx)
+
+	// Give the process a moment to start
+	time.Sleep(100 * time.Millisecond)
+
+	if err := transport.Stop(); err != nil {
+		t.Errorf("stop failed: %v", err)
+	}
+	if err := transport.ExitError(); err != nil {
+		t.Errorf("process should exit on its own after stdin closes, got %v", err)
+	}
+}
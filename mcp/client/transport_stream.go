@@ -0,0 +1,136 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+// StreamTransport exchanges newline-delimited JSON-RPC messages over a reader and a writer
+type StreamTransport struct {
+	reader        io.Reader
+	writer        io.Writer
+	writerMutex   sync.Mutex
+	readerChannel chan messages.JsonRPCMessage
+	logger        *server.Logger
+	stopOnce      sync.Once
+}
+
+func NewStreamTransport(reader io.Reader, writer io.Writer) *StreamTransport {
+	const channelCapacity = 100
+	logger := server.NewLogger("StreamTransport")
+	logger.Out = os.Stderr
+	logger.ErrOut = os.Stderr
+	return &StreamTransport{
+		reader:        reader,
+		writer:        writer,
+		readerChannel: make(chan messages.JsonRPCMessage, channelCapacity),
+		logger:        logger,
+	}
+}
+
+func (s *StreamTransport) Read() <-chan messages.JsonRPCMessage {
+	return s.readerChannel
+}
+
+func (s *StreamTransport) Write(msg messages.JsonRPCMessage, ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	marshaled, err := json.Marshal(msg)
+	if err != nil {
+		s.logger.Error("Failed to marshal message: %v", err)
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	s.writerMutex.Lock()
+	defer s.writerMutex.Unlock()
+
+	if _, err := s.writer.Write(append(marshaled, '\n')); err != nil {
+		s.logger.Error("Failed to write message: %v", err)
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+func (s *StreamTransport) Start(ctx context.Context) error {
+	s.logger.Info("Starting stream transport")
+	defer s.logger.Info("Stream transport stopped")
+	defer close(s.readerChannel)
+
+	lineCh := make(chan []byte, 10)
+	scanErrCh := make(chan error, 1)
+
+	go func() {
+		defer close(lineCh)
+
+		scanner := bufio.NewScanner(s.reader)
+		const maxScannerBuffer = 1024 * 1024 // 1MB
+		scanner.Buffer(make([]byte, maxScannerBuffer), maxScannerBuffer)
+
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			if len(line) == 0 {
+				continue
+			}
+			select {
+			case lineCh <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			scanErrCh <- fmt.Errorf("scanner error: %w", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Context cancelled, stopping transport: %v", ctx.Err())
+			return fmt.Errorf("transport stopped: %w", ctx.Err())
+
+		case err := <-scanErrCh:
+			s.logger.Error("Scanner error: %v", err)
+			return err
+
+		case line, ok := <-lineCh:
+			if !ok {
+				s.logger.Info("End of input reached, transport stopping normally")
+				return nil
+			}
+
+			var msg messages.JsonRPCMessage
+			if err := json.Unmarshal(line, &msg); err != nil {
+				s.logger.Error("Error parsing JSON: %v", err)
+				continue
+			}
+
+			select {
+			case s.readerChannel <- msg:
+			case <-ctx.Done():
+				return fmt.Errorf("transport stopped while sending message: %w", ctx.Err())
+			}
+		}
+	}
+}
+
+func (s *StreamTransport) Stop() error {
+	var err error
+	s.stopOnce.Do(func() {
+		s.logger.Info("Closing transport")
+		if closer, ok := s.writer.(io.Closer); ok {
+			err = closer.Close()
+		}
+	})
+	return err
+}
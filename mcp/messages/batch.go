@@ -0,0 +1,55 @@
+package messages
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+var ErrEmptyBatch = errors.New("empty batch")
+
+type JsonRPCBatch struct {
+	Messages []JsonRPCMessage
+
+	SessionID string
+	// Tag lets a transport correlate a reply with the batch it answers, it is copied onto the reply
+	Tag interface{}
+}
+
+func (b JsonRPCBatch) MarshalJSON() ([]byte, error) {
+	if b.Messages == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(b.Messages)
+}
+
+func IsBatch(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// DecodeBatch decodes a JSON array of messages. Elements that are not valid message objects
+// are kept as empty messages so that the receiver can answer them with an Invalid Request error.
+func DecodeBatch(data []byte) (JsonRPCBatch, error) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return JsonRPCBatch{}, err
+	}
+
+	if len(elements) == 0 {
+		return JsonRPCBatch{}, ErrEmptyBatch
+	}
+
+	batch := JsonRPCBatch{
+		Messages: make([]JsonRPCMessage, len(elements)),
+	}
+	for i, element := range elements {
+		var msg JsonRPCMessage
+		if err := json.Unmarshal(element, &msg); err != nil {
+			msg = JsonRPCMessage{}
+		}
+		batch.Messages[i] = msg
+	}
+
+	return batch, nil
+}
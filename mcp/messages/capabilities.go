@@ -0,0 +1,14 @@
+package messages
+
+type CapabilityProperties struct {
+	ListChanged *bool `json:"listChanged,omitempty"`
+	Subscribe   *bool `json:"subscribe,omitempty"`
+}
+
+// ClientCapabilities is what a client declares in initialize, shared by the client that sends it and the server that reads it
+type ClientCapabilities struct {
+	Roots        *CapabilityProperties  `json:"roots,omitempty"`
+	Sampling     *CapabilityProperties  `json:"sampling,omitempty"`
+	Elicitation  *CapabilityProperties  `json:"elicitation,omitempty"`
+	Experimental map[string]interface{} `json:"experimental,omitempty"`
+}
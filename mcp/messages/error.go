@@ -6,4 +6,7 @@ const (
 	JsonRPCErrorMethodNotFound = -32601
 	JsonRPCErrorInvalidParams  = -32602
 	JsonRPCErrorInternalError  = -32603
+
+	JsonRPCErrorServerBusy       = -32000
+	JsonRPCErrorResourceNotFound = -32002
 )
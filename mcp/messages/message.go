@@ -1,5 +1,7 @@
 package messages
 
+import "encoding/json"
+
 type JsonRPCResult map[string]interface{}
 type JsonRPCParams map[string]interface{}
 
@@ -10,6 +12,23 @@ type JsonRPCMessage struct {
 	Params  *JsonRPCParams `json:"params,omitempty"`
 	Result  *JsonRPCResult `json:"result,omitempty"`
 	Error   *ErrorResponse `json:"error,omitempty"`
+
+	// SessionID is set by transports that serve more than one client, it is never serialized
+	SessionID string `json:"-"`
+	// RelatedRequestID names the client request an outgoing message belongs to, transports may send it along with that request's response
+	RelatedRequestID interface{} `json:"-"`
+}
+
+// MarshalJSON keeps "id": null on error responses to messages whose ID could not be determined
+func (j JsonRPCMessage) MarshalJSON() ([]byte, error) {
+	type plainMessage JsonRPCMessage
+	if j.Error != nil && j.ID == nil {
+		return json.Marshal(struct {
+			plainMessage
+			ID interface{} `json:"id"`
+		}{plainMessage(j), nil})
+	}
+	return json.Marshal(plainMessage(j))
 }
 
 func NewJsonRPCMessage() *JsonRPCMessage {
@@ -18,6 +37,23 @@ func NewJsonRPCMessage() *JsonRPCMessage {
 	}
 }
 
+// IsValidID reports whether id is absent, a string or a number, the only ID types JSON-RPC allows
+func IsValidID(id interface{}) bool {
+	switch id.(type) {
+	case nil, string, float64, int, int64, json.Number:
+		return true
+	}
+	return false
+}
+
+// NormalizeID maps whole numbers decoded from JSON back to int64, so a response ID matches the ID its request was sent with
+func NormalizeID(id interface{}) interface{} {
+	if number, ok := id.(float64); ok && number == float64(int64(number)) {
+		return int64(number)
+	}
+	return id
+}
+
 func (j *JsonRPCMessage) IsNotification() bool {
 	return j.ID == nil && j.Method != nil
 }
@@ -2,6 +2,7 @@ package messages_test
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/alwint3r/mcp2go/mcp/messages"
@@ -317,3 +318,92 @@ func TestRawJsonDeserialization(t *testing.T) {
 		}
 	})
 }
+
+func TestBatchDecoding(t *testing.T) {
+	t.Run("MixedBatch", func(t *testing.T) {
+		data := []byte(` [{"jsonrpc":"2.0","id":1,"method":"ping"}, 1, {"jsonrpc":"2.0","method":"notifications/initialized"}]`)
+		if !messages.IsBatch(data) {
+			t.Fatalf("data should be detected as a batch")
+		}
+
+		batch, err := messages.DecodeBatch(data)
+		if err != nil {
+			t.Fatalf("failed to decode batch: %v", err)
+		}
+
+		if len(batch.Messages) != 3 {
+			t.Fatalf("batch should have 3 messages, got %d", len(batch.Messages))
+		}
+
+		if !batch.Messages[0].IsRequest() {
+			t.Errorf("first element should be a request")
+		}
+
+		invalid := batch.Messages[1]
+		if invalid.IsRequest() || invalid.IsNotification() || invalid.IsResponse() {
+			t.Errorf("invalid element should not match any message type")
+		}
+
+		if !batch.Messages[2].IsNotification() {
+			t.Errorf("third element should be a notification")
+		}
+	})
+
+	t.Run("EmptyBatch", func(t *testing.T) {
+		_, err := messages.DecodeBatch([]byte(`[]`))
+		if !errors.Is(err, messages.ErrEmptyBatch) {
+			t.Errorf("expected ErrEmptyBatch, got %v", err)
+		}
+	})
+
+	t.Run("ErrorWithNullID", func(t *testing.T) {
+		response := messages.NewJsonRPCMessage()
+		response.Error = &messages.ErrorResponse{
+			Code:    messages.JsonRPCErrorInvalidRequest,
+			Message: "Invalid Request",
+		}
+
+		jsonData, err := json.Marshal(response)
+		if err != nil {
+			t.Fatalf("failed to marshal error response: %v", err)
+		}
+
+		var raw map[string]interface{}
+		json.Unmarshal(jsonData, &raw)
+		if id, exist := raw["id"]; !exist || id != nil {
+			t.Errorf("error response should carry a null id, got %s", jsonData)
+		}
+	})
+}
+
+func TestIsValidID(t *testing.T) {
+	var decoded struct {
+		IDs []interface{} `json:"ids"`
+	}
+	if err := json.Unmarshal([]byte(`{"ids":[1,"a",null,[1],{"x":1},true]}`), &decoded); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	expected := []bool{true, true, true, false, false, false}
+	for i, id := range decoded.IDs {
+		if valid := messages.IsValidID(id); valid != expected[i] {
+			t.Errorf("IsValidID(%v) = %v, expected %v", id, valid, expected[i])
+		}
+	}
+}
+
+func TestNormalizeID(t *testing.T) {
+	var decoded struct {
+		IDs []interface{} `json:"ids"`
+	}
+	if err := json.Unmarshal([]byte(`{"ids":[7,1.5,"7"]}`), &decoded); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	expected := []interface{}{int64(7), 1.5, "7"}
+	for i, id := range decoded.IDs {
+		if normalized := messages.NormalizeID(id); normalized != expected[i] {
+			t.Errorf("NormalizeID(%v) = %#v, expected %#v", id, normalized, expected[i])
+		}
+	}
+}
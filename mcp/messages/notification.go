@@ -5,3 +5,14 @@ type Notification struct {
 	Method  string                  `json:"method"`
 	Params  *map[string]interface{} `json:"params"`
 }
+
+func (n *Notification) ToJsonRPCMessage() *JsonRPCMessage {
+	message := NewJsonRPCMessage()
+	method := n.Method
+	message.Method = &method
+	if n.Params != nil {
+		params := JsonRPCParams(*n.Params)
+		message.Params = &params
+	}
+	return message
+}
@@ -0,0 +1,19 @@
+package messages
+
+const loggingMessageMethodName = "notifications/message"
+
+func NewLoggingMessageNotification(level string, logger string, data interface{}) *Notification {
+	params := map[string]interface{}{
+		"level": level,
+		"data":  data,
+	}
+	if logger != "" {
+		params["logger"] = logger
+	}
+
+	return &Notification{
+		JsonRPC: "2.0",
+		Method:  loggingMessageMethodName,
+		Params:  &params,
+	}
+}
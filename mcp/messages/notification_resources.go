@@ -0,0 +1,13 @@
+package messages
+
+const resourceUpdatedMethodName = "notifications/resources/updated"
+
+func NewResourceUpdatedNotification(uri string) *Notification {
+	return &Notification{
+		JsonRPC: "2.0",
+		Method:  resourceUpdatedMethodName,
+		Params: &map[string]interface{}{
+			"uri": uri,
+		},
+	}
+}
@@ -0,0 +1,10 @@
+package messages
+
+const toolListChangedMethodName = "notifications/tools/list_changed"
+
+func NewToolListChangedNotification() *Notification {
+	return &Notification{
+		JsonRPC: "2.0",
+		Method:  toolListChangedMethodName,
+	}
+}
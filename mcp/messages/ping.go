@@ -29,3 +29,19 @@ func NewPingRequest(requestID interface{}) *PingRequest {
 		Method:  pingRequestMethodName,
 	}
 }
+
+func (p *PingRequest) ToJsonRPCMessage() *JsonRPCMessage {
+	message := NewJsonRPCMessage()
+	method := p.Method
+	message.ID = p.ID
+	message.Method = &method
+	return message
+}
+
+func (p *PingResponse) ToJsonRPCMessage() *JsonRPCMessage {
+	message := NewJsonRPCMessage()
+	result := JsonRPCResult(p.Result)
+	message.ID = p.ID
+	message.Result = &result
+	return message
+}
@@ -2,20 +2,27 @@ package messages
 
 const progressNotificationMethodName = "notifications/progress"
 
-func NewProgressNotification(progressToken string, progress float32, total float32, message string) *Notification {
+// NewProgressNotification builds a progress notification, the token is a string or an integer and total and message are omitted when zero
+func NewProgressNotification(progressToken interface{}, progress float32, total float32, message string) *Notification {
+	params := map[string]interface{}{
+		"progressToken": progressToken,
+		"progress":      progress,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+
 	return &Notification{
 		JsonRPC: "2.0",
 		Method:  progressNotificationMethodName,
-		Params: &map[string]interface{}{
-			"progressToken": progressToken,
-			"progress":      progress,
-			"total":         total,
-			"message":       message,
-		},
+		Params:  &params,
 	}
 }
 
-func WithProgress(request *Request, progressToken string) *Request {
+func WithProgress(request *Request, progressToken interface{}) *Request {
 	if request.Params == nil {
 		request.Params = &JsonRPCParams{}
 	}
@@ -30,3 +37,21 @@ func WithProgress(request *Request, progressToken string) *Request {
 
 	return request
 }
+
+// ProgressToken returns the progress token a request asked for in its _meta, if any
+func ProgressToken(params *JsonRPCParams) (interface{}, bool) {
+	if params == nil {
+		return nil, false
+	}
+
+	meta, ok := (*params)["_meta"].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	switch token := meta["progressToken"].(type) {
+	case string, float64, int, int64:
+		return token, true
+	}
+	return nil, false
+}
@@ -5,6 +5,8 @@ type Request struct {
 	ID      interface{}    `json:"id"`
 	Method  string         `json:"method"`
 	Params  *JsonRPCParams `json:"params,omitempty"`
+
+	SessionID string `json:"-"`
 }
 
 func NewRequestFromJsonRPCMessage(message JsonRPCMessage) Request {
@@ -13,5 +15,17 @@ func NewRequestFromJsonRPCMessage(message JsonRPCMessage) Request {
 		ID:      message.ID,
 		Method:  *message.Method,
 		Params:  message.Params,
+
+		SessionID: message.SessionID,
 	}
 }
+
+func (r *Request) ToJsonRPCMessage() *JsonRPCMessage {
+	message := NewJsonRPCMessage()
+	method := r.Method
+	message.ID = r.ID
+	message.Method = &method
+	message.Params = r.Params
+	message.SessionID = r.SessionID
+	return message
+}
@@ -1,6 +1,9 @@
 package messages
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 type ErrorResponse struct {
 	Code    int64        `json:"code"`
@@ -8,6 +11,10 @@ type ErrorResponse struct {
 	Data    *interface{} `json:"data"`
 }
 
+func (e *ErrorResponse) Error() string {
+	return fmt.Sprintf("json-rpc error %d: %s", e.Code, e.Message)
+}
+
 type Response struct {
 	JsonRPC string                  `json:"jsonrpc"`
 	ID      interface{}             `json:"id"`
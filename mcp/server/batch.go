@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+)
+
+func newInvalidRequestResponse(msg *messages.JsonRPCMessage, reason string) *messages.JsonRPCMessage {
+	errResponse := messages.NewJsonRPCMessage()
+	errResponse.ID = msg.ID
+	errResponse.SessionID = msg.SessionID
+	errResponse.Error = &messages.ErrorResponse{
+		Code:    messages.JsonRPCErrorInvalidRequest,
+		Message: reason,
+	}
+	return errResponse
+}
+
+// newInvalidIDResponse answers a request whose ID is not a string or number, the ID can't be echoed so it is null
+func newInvalidIDResponse(msg *messages.JsonRPCMessage) *messages.JsonRPCMessage {
+	errResponse := newInvalidRequestResponse(msg, "Invalid Request: id must be a string or number")
+	errResponse.ID = nil
+	return errResponse
+}
+
+// dispatchBatchMessage handles a single batch element and returns its response, or nil when it has none
+func (s *DefaultServer) dispatchBatchMessage(ctx context.Context, msg *messages.JsonRPCMessage) *messages.JsonRPCMessage {
+	if msg.JsonRPC != "2.0" {
+		s.logger.Warn("Received batch element with invalid JSON-RPC version: %s", msg.JsonRPC)
+		return newInvalidRequestResponse(msg, "Invalid JSON-RPC protocol version")
+	}
+	if !messages.IsValidID(msg.ID) {
+		s.logger.Warn("Received batch element with invalid ID type %T", msg.ID)
+		if msg.Method == nil {
+			return nil
+		}
+		return newInvalidIDResponse(msg)
+	}
+
+	s.sessionFor(msg.SessionID)
+
+	if msg.IsRequest() {
+		request := messages.NewRequestFromJsonRPCMessage(*msg)
+		s.logger.Debug("Handling batched request: %s (ID: %v)", request.Method, request.ID)
+		return s.handleRequest(ctx, request)
+	} else if msg.IsNotification() {
+		s.logger.Debug("Received batched notification: %s", *msg.Method)
+		s.handleNotification(ctx, msg)
+		return nil
+	} else if msg.IsResponse() {
+		s.logger.Debug("Received batched response message with ID: %v", msg.ID)
+		s.handleResponse(msg)
+		return nil
+	}
+
+	s.logger.Warn("Received invalid batch element")
+	return newInvalidRequestResponse(msg, "Invalid message type")
+}
+
+func (s *DefaultServer) handleBatchFromTransport(ctx context.Context, batch messages.JsonRPCBatch) {
+	s.logger.Debug("Handling batch of %d messages", len(batch.Messages))
+
+	responses := make([]*messages.JsonRPCMessage, len(batch.Messages))
+	var wg sync.WaitGroup
+	for i := range batch.Messages {
+		msg := &batch.Messages[i]
+		if msg.SessionID == "" {
+			msg.SessionID = batch.SessionID
+		}
+
+		wg.Add(1)
+		job := func() {
+			defer wg.Done()
+			responses[i] = s.dispatchBatchMessage(ctx, msg)
+		}
+		reject := func() {
+			responses[i] = newServerStoppedResponse(msg)
+			wg.Done()
+		}
+		if !msg.IsRequest() {
+			go job()
+		} else if err := s.scheduler.submit(job, reject); err != nil {
+			s.logger.Warn("Rejecting batched request %v: %v", msg.ID, err)
+			responses[i] = newServerBusyResponse(msg)
+			wg.Done()
+		}
+	}
+	wg.Wait()
+
+	reply := messages.JsonRPCBatch{
+		Messages:  make([]messages.JsonRPCMessage, 0, len(responses)),
+		SessionID: batch.SessionID,
+		Tag:       batch.Tag,
+	}
+	for _, response := range responses {
+		if response != nil {
+			reply.Messages = append(reply.Messages, *response)
+		}
+	}
+
+	// A batch of notifications and responses gets no reply at all
+	if len(reply.Messages) == 0 {
+		return
+	}
+
+	batchTransport, ok := s.transport.(BatchTransport)
+	if !ok {
+		s.logger.Error("Transport does not support batches")
+		return
+	}
+
+	// Requests rejected because the server stopped are answered even once its context is done
+	withTimeoutCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.config.OutgoingMessageTimeoutSeconds*time.Second)
+	defer cancel()
+	if err := batchTransport.WriteBatch(reply, withTimeoutCtx); err != nil {
+		s.logger.Error("Failed to write batch response: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+)
+
+const maxCompletionValues = 100
+
+var ErrUnknownCompletionArgument = errors.New("unknown completion argument")
+
+type CompletionResult struct {
+	Values  []string `json:"values"`
+	Total   int      `json:"total,omitempty"`
+	HasMore bool     `json:"hasMore,omitempty"`
+}
+
+// CompletionProvider receives the partial value and the arguments the client already resolved
+type CompletionProvider func(ctx context.Context, value string, arguments map[string]string) (CompletionResult, error)
+type CompletionProvidersMap map[string]CompletionProvider
+
+// complete runs the provider registered for an argument, arguments without one complete to nothing
+func complete(ctx context.Context, providers CompletionProvidersMap, name string, value string, arguments map[string]string) (CompletionResult, error) {
+	provider, exist := providers[name]
+	if !exist {
+		return CompletionResult{Values: make([]string, 0)}, nil
+	}
+
+	result, err := provider(ctx, value, arguments)
+	if err != nil {
+		return CompletionResult{}, err
+	}
+
+	if result.Values == nil {
+		result.Values = make([]string, 0)
+	}
+	if len(result.Values) > maxCompletionValues {
+		if result.Total < len(result.Values) {
+			result.Total = len(result.Values)
+		}
+		result.Values = result.Values[:maxCompletionValues]
+		result.HasMore = true
+	}
+	return result, nil
+}
+
+type completeParams struct {
+	Ref struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+		URI  string `json:"uri"`
+	} `json:"ref"`
+	Argument struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"argument"`
+	Context struct {
+		Arguments map[string]string `json:"arguments"`
+	} `json:"context"`
+}
+
+func newCompletionParamsError(err error, message string) *RequestError {
+	return &RequestError{
+		Err: err,
+		ForResponse: messages.ErrorResponse{
+			Code:    messages.JsonRPCErrorInvalidParams,
+			Message: message,
+		},
+	}
+}
+
+func (s *DefaultServer) handleCompletionRequest(ctx context.Context, request messages.Request) (*messages.JsonRPCResult, *RequestError) {
+	var params completeParams
+	if err := decodeParams(request.Params, &params); err != nil || params.Argument.Name == "" {
+		return nil, newCompletionParamsError(err, "invalid completion params")
+	}
+
+	var result CompletionResult
+	var err error
+	switch params.Ref.Type {
+	case "ref/prompt":
+		if s.promptManager == nil {
+			return nil, newCompletionParamsError(nil, "prompts are not supported")
+		}
+		result, err = s.promptManager.CompletePromptArgument(ctx, params.Ref.Name, params.Argument.Name, params.Argument.Value, params.Context.Arguments)
+	case "ref/resource":
+		if s.resourceManager == nil {
+			return nil, newCompletionParamsError(nil, "resources are not supported")
+		}
+		result, err = s.resourceManager.CompleteTemplateVariable(ctx, params.Ref.URI, params.Argument.Name, params.Argument.Value, params.Context.Arguments)
+	default:
+		return nil, newCompletionParamsError(nil, fmt.Sprintf("invalid completion reference type %q", params.Ref.Type))
+	}
+
+	if errors.Is(err, ErrPromptNotFound) || errors.Is(err, ErrResourceNotFound) {
+		return nil, newCompletionParamsError(err, err.Error())
+	} else if err != nil {
+		s.logger.Error("Failed to complete %s: %v", params.Argument.Name, err)
+		return nil, &RequestError{
+			Err: err,
+			ForResponse: messages.ErrorResponse{
+				Code:    messages.JsonRPCErrorInternalError,
+				Message: fmt.Sprintf("failed to complete argument: %v", err),
+			},
+		}
+	}
+
+	response := messages.JsonRPCResult{
+		"completion": result,
+	}
+	return &response, nil
+}
@@ -10,9 +10,15 @@ import (
 type ServerConfig struct {
 	LogLevel                      LogLevel      `json:"logLevel"`
 	ShowTimestamps                bool          `json:"showTimestamps"`
-	MaxRequestActive              int           `json:"maxRequestActive"`
+	MaxRequestActive              int           `json:"maxRequestActive"` // 0 runs every request in its own goroutine
+	MaxRequestQueued              int           `json:"maxRequestQueued"`
+	RequestQueuePolicy            QueuePolicy   `json:"requestQueuePolicy"`
 	LogFile                       string        `json:"logFile"`
 	OutgoingMessageTimeoutSeconds time.Duration `json:"outgoingMessageTimeoutSeconds"`
+	PingIntervalSeconds           time.Duration `json:"pingIntervalSeconds"`   // 0 disables server-initiated pings
+	PingTimeoutSeconds            time.Duration `json:"pingTimeoutSeconds"`    // 0 uses the default timeout
+	RequestTimeoutSeconds         time.Duration `json:"requestTimeoutSeconds"` // upper bound for server-to-client requests, 0 uses the default timeout
+	ProgressIntervalMilliseconds  time.Duration `json:"progressIntervalMilliseconds"`
 }
 
 func NewDefaultConfig() ServerConfig {
@@ -20,8 +26,14 @@ func NewDefaultConfig() ServerConfig {
 		LogLevel:                      LogInfo,
 		ShowTimestamps:                true,
 		MaxRequestActive:              10,
+		MaxRequestQueued:              100,
+		RequestQueuePolicy:            QueuePolicyWait,
 		LogFile:                       "",
 		OutgoingMessageTimeoutSeconds: 15, // Increased from 5 to 15 seconds
+		PingIntervalSeconds:           0,
+		PingTimeoutSeconds:            10,
+		RequestTimeoutSeconds:         60,
+		ProgressIntervalMilliseconds:  100,
 	}
 }
 
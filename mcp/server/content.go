@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	ContentTypeText         = "text"
+	ContentTypeImage        = "image"
+	ContentTypeAudio        = "audio"
+	ContentTypeResource     = "resource"
+	ContentTypeResourceLink = "resource_link"
+)
+
+// Annotations tell clients who a content block is meant for and how important it is
+type Annotations struct {
+	Audience     []string `json:"audience,omitempty"`     // RoleUser and/or RoleAssistant
+	Priority     *float64 `json:"priority,omitempty"`     // 0 is least and 1 is most important
+	LastModified string   `json:"lastModified,omitempty"` // ISO 8601 timestamp
+}
+
+func TextContent(text string) ToolCallContent {
+	return ToolCallContent{
+		Type: ContentTypeText,
+		Text: &text,
+	}
+}
+
+func ImageContent(data []byte, mimeType string) ToolCallContent {
+	return binaryContent(ContentTypeImage, data, mimeType)
+}
+
+func AudioContent(data []byte, mimeType string) ToolCallContent {
+	return binaryContent(ContentTypeAudio, data, mimeType)
+}
+
+func binaryContent(contentType string, data []byte, mimeType string) ToolCallContent {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	return ToolCallContent{
+		Type:     contentType,
+		Data:     &encoded,
+		MimeType: &mimeType,
+	}
+}
+
+func EmbeddedResource(resource ResourceContents) ToolCallContent {
+	return ToolCallContent{
+		Type:     ContentTypeResource,
+		Resource: &resource,
+	}
+}
+
+// ResourceLink points at a resource the client can read later instead of embedding it, mimeType may be empty
+func ResourceLink(uri string, name string, mimeType string) ToolCallContent {
+	content := ToolCallContent{
+		Type: ContentTypeResourceLink,
+		URI:  uri,
+		Name: name,
+	}
+	if mimeType != "" {
+		content.MimeType = &mimeType
+	}
+	return content
+}
+
+func (c ToolCallContent) WithAnnotations(annotations Annotations) ToolCallContent {
+	c.Annotations = &annotations
+	return c
+}
+
+// ImageContentFromReader reads an image, the MIME type is sniffed from the data when mimeType is empty
+func ImageContentFromReader(reader io.Reader, mimeType string) (ToolCallContent, error) {
+	data, mimeType, err := readMedia(reader, mimeType, "image/")
+	if err != nil {
+		return ToolCallContent{}, err
+	}
+	return ImageContent(data, mimeType), nil
+}
+
+// AudioContentFromReader reads audio, the MIME type is sniffed from the data when mimeType is empty
+func AudioContentFromReader(reader io.Reader, mimeType string) (ToolCallContent, error) {
+	data, mimeType, err := readMedia(reader, mimeType, "audio/")
+	if err != nil {
+		return ToolCallContent{}, err
+	}
+	return AudioContent(data, mimeType), nil
+}
+
+func readMedia(reader io.Reader, mimeType string, prefix string) ([]byte, string, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read media: %w", err)
+	}
+	if mimeType != "" {
+		return data, mimeType, nil
+	}
+
+	sniffed, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	// Ogg is sniffed as a container, treat it as audio when audio is expected
+	if sniffed == "application/ogg" && prefix == "audio/" {
+		sniffed = "audio/ogg"
+	}
+	if !strings.HasPrefix(sniffed, prefix) {
+		return nil, "", fmt.Errorf("detected MIME type %s is not %s*", sniffed, prefix)
+	}
+	return data, sniffed, nil
+}
@@ -0,0 +1,69 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+func TestContentMarshal(t *testing.T) {
+	priority := 0.5
+	cases := []struct {
+		content server.ToolCallContent
+		want    string
+	}{
+		{server.TextContent(""), `{"type":"text","text":""}`},
+		{server.ImageContent([]byte("png"), "image/png"), `{"type":"image","data":"cG5n","mimeType":"image/png"}`},
+		{server.AudioContent([]byte("wav"), "audio/wav"), `{"type":"audio","data":"d2F2","mimeType":"audio/wav"}`},
+		{
+			server.EmbeddedResource(server.NewTextResourceContents("file:///a.txt", "text/plain", "hello")),
+			`{"type":"resource","resource":{"uri":"file:///a.txt","mimeType":"text/plain","text":"hello"}}`,
+		},
+		{server.ResourceLink("file:///b.txt", "b.txt", ""), `{"type":"resource_link","uri":"file:///b.txt","name":"b.txt"}`},
+		{
+			server.TextContent("hi").WithAnnotations(server.Annotations{Audience: []string{server.RoleUser}, Priority: &priority}),
+			`{"type":"text","text":"hi","annotations":{"audience":["user"],"priority":0.5}}`,
+		},
+	}
+
+	for _, c := range cases {
+		marshaled, err := json.Marshal(c.content)
+		if err != nil {
+			t.Fatalf("failed to marshal %s content: %v", c.content.Type, err)
+		}
+		if string(marshaled) != c.want {
+			t.Errorf("expected %s, got %s", c.want, marshaled)
+		}
+	}
+}
+
+func TestContentFromReader(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	image, err := server.ImageContentFromReader(bytes.NewReader(png), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if image.Type != "image" || *image.MimeType != "image/png" {
+		t.Errorf("expected image/png content, got %s %s", image.Type, *image.MimeType)
+	}
+
+	wav := []byte("RIFF\x00\x00\x00\x00WAVEfmt ")
+	audio, err := server.AudioContentFromReader(bytes.NewReader(wav), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if audio.Type != "audio" || *audio.MimeType != "audio/wave" {
+		t.Errorf("expected audio/wave content, got %s %s", audio.Type, *audio.MimeType)
+	}
+
+	if _, err := server.AudioContentFromReader(bytes.NewReader(png), ""); err == nil {
+		t.Errorf("expected an error for image data read as audio")
+	}
+
+	explicit, err := server.AudioContentFromReader(bytes.NewReader([]byte("raw")), "audio/L16")
+	if err != nil || *explicit.MimeType != "audio/L16" {
+		t.Errorf("expected the given MIME type to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+)
+
+var (
+	ErrElicitationNotSupported  = errors.New("client does not support elicitation")
+	ErrInvalidElicitationSchema = errors.New("invalid elicitation schema")
+	ErrInvalidElicitedContent   = errors.New("invalid elicited content")
+)
+
+const (
+	ElicitationAccept  = "accept"
+	ElicitationDecline = "decline"
+	ElicitationCancel  = "cancel"
+)
+
+type ElicitationResult struct {
+	Action  string                 `json:"action"`
+	Content map[string]interface{} `json:"content,omitempty"`
+}
+
+// Elicit asks the user of the client of the request being handled in ctx for input matching a flat object schema
+func Elicit(ctx context.Context, message string, requestedSchema map[string]interface{}) (*ElicitationResult, error) {
+	s := serverFromContext(ctx)
+	current := sessionFromContext(ctx)
+	if s == nil || current == nil {
+		return nil, ErrNoClientSession
+	}
+	if current.sessionInfo().ClientCapabilities.Elicitation == nil {
+		return nil, ErrElicitationNotSupported
+	}
+	if err := validateElicitationSchema(requestedSchema); err != nil {
+		return nil, err
+	}
+
+	params := messages.JsonRPCParams{
+		"message":         message,
+		"requestedSchema": requestedSchema,
+	}
+	response, err := s.SendRequestToSession(ctx, current.id, "elicitation/create", &params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to elicit input: %w", err)
+	}
+
+	var result ElicitationResult
+	if err := remarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode elicitation result: %w", err)
+	}
+
+	switch result.Action {
+	case ElicitationAccept:
+		if err := validateElicitedContent(requestedSchema, result.Content); err != nil {
+			return nil, err
+		}
+	case ElicitationDecline, ElicitationCancel:
+		result.Content = nil
+	default:
+		return nil, fmt.Errorf("unknown elicitation action %q", result.Action)
+	}
+	return &result, nil
+}
+
+func elicitationProperties(schema map[string]interface{}) map[string]interface{} {
+	properties, _ := schema["properties"].(map[string]interface{})
+	return properties
+}
+
+func elicitationRequired(schema map[string]interface{}) []string {
+	var required []string
+	switch names := schema["required"].(type) {
+	case []string:
+		required = names
+	case []interface{}:
+		for _, name := range names {
+			if text, ok := name.(string); ok {
+				required = append(required, text)
+			}
+		}
+	}
+	return required
+}
+
+// validateElicitationSchema accepts only objects whose properties are strings, numbers, integers or booleans
+func validateElicitationSchema(schema map[string]interface{}) error {
+	if schema["type"] != "object" {
+		return fmt.Errorf("%w: type must be object", ErrInvalidElicitationSchema)
+	}
+
+	properties := elicitationProperties(schema)
+	for name, property := range properties {
+		definition, ok := property.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%w: property %s is not a schema", ErrInvalidElicitationSchema, name)
+		}
+		switch definition["type"] {
+		case "string", "number", "integer", "boolean":
+		default:
+			return fmt.Errorf("%w: property %s must be a primitive type", ErrInvalidElicitationSchema, name)
+		}
+	}
+
+	for _, name := range elicitationRequired(schema) {
+		if _, exist := properties[name]; !exist {
+			return fmt.Errorf("%w: required property %s is not defined", ErrInvalidElicitationSchema, name)
+		}
+	}
+	return nil
+}
+
+func numberBound(definition map[string]interface{}, key string) (float64, bool) {
+	switch bound := definition[key].(type) {
+	case float64:
+		return bound, true
+	case int:
+		return float64(bound), true
+	}
+	return 0, false
+}
+
+func validateElicitedValue(definition map[string]interface{}, value interface{}) error {
+	switch definition["type"] {
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return errors.New("must be a string")
+		}
+		if minLength, ok := numberBound(definition, "minLength"); ok && float64(utf8.RuneCountInString(text)) < minLength {
+			return fmt.Errorf("must be at least %v characters", minLength)
+		}
+		if maxLength, ok := numberBound(definition, "maxLength"); ok && float64(utf8.RuneCountInString(text)) > maxLength {
+			return fmt.Errorf("must be at most %v characters", maxLength)
+		}
+		if options, ok := definition["enum"]; ok && !containsOption(options, text) {
+			return errors.New("must be one of the allowed values")
+		}
+	case "number", "integer":
+		number, ok := value.(float64)
+		if !ok {
+			return errors.New("must be a number")
+		}
+		if definition["type"] == "integer" && number != math.Trunc(number) {
+			return errors.New("must be an integer")
+		}
+		if minimum, ok := numberBound(definition, "minimum"); ok && number < minimum {
+			return fmt.Errorf("must be at least %v", minimum)
+		}
+		if maximum, ok := numberBound(definition, "maximum"); ok && number > maximum {
+			return fmt.Errorf("must be at most %v", maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return errors.New("must be a boolean")
+		}
+	}
+	return nil
+}
+
+func containsOption(options interface{}, text string) bool {
+	switch values := options.(type) {
+	case []string:
+		for _, option := range values {
+			if option == text {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, option := range values {
+			if option == text {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateElicitedContent checks accepted content against the schema that was sent to the client
+func validateElicitedContent(schema map[string]interface{}, content map[string]interface{}) error {
+	var problems []string
+	properties := elicitationProperties(schema)
+
+	for _, name := range elicitationRequired(schema) {
+		if _, exist := content[name]; !exist {
+			problems = append(problems, fmt.Sprintf("%s is required", name))
+		}
+	}
+	for name, value := range content {
+		definition, exist := properties[name].(map[string]interface{})
+		if !exist {
+			problems = append(problems, fmt.Sprintf("%s is not expected", name))
+			continue
+		}
+		if err := validateElicitedValue(definition, value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %v", name, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%w: %s", ErrInvalidElicitedContent, strings.Join(problems, ", "))
+	}
+	return nil
+}
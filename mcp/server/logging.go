@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+)
+
+// LoggingLevel is one of the syslog severities a client can select with logging/setLevel
+type LoggingLevel string
+
+const (
+	LoggingDebug     LoggingLevel = "debug"
+	LoggingInfo      LoggingLevel = "info"
+	LoggingNotice    LoggingLevel = "notice"
+	LoggingWarning   LoggingLevel = "warning"
+	LoggingError     LoggingLevel = "error"
+	LoggingCritical  LoggingLevel = "critical"
+	LoggingAlert     LoggingLevel = "alert"
+	LoggingEmergency LoggingLevel = "emergency"
+)
+
+var loggingLevels = []LoggingLevel{
+	LoggingDebug,
+	LoggingInfo,
+	LoggingNotice,
+	LoggingWarning,
+	LoggingError,
+	LoggingCritical,
+	LoggingAlert,
+	LoggingEmergency,
+}
+
+// severity orders the levels from debug upwards, unknown levels return -1
+func (l LoggingLevel) severity() int {
+	for i, level := range loggingLevels {
+		if level == l {
+			return i
+		}
+	}
+	return -1
+}
+
+// localLevel maps a client level onto the local logger, never LogFatal since that exits the process
+func (l LoggingLevel) localLevel() LogLevel {
+	switch l {
+	case LoggingDebug:
+		return LogDebug
+	case LoggingInfo, LoggingNotice:
+		return LogInfo
+	case LoggingWarning:
+		return LogWarn
+	default:
+		return LogError
+	}
+}
+
+func (s *DefaultServer) handleLoggingSetLevelRequest(ctx context.Context, request messages.Request) (*messages.JsonRPCResult, *RequestError) {
+	var level LoggingLevel
+	if request.Params != nil {
+		text, _ := (*request.Params)["level"].(string)
+		level = LoggingLevel(text)
+	}
+	if level.severity() < 0 {
+		return nil, &RequestError{
+			Err: nil,
+			ForResponse: messages.ErrorResponse{
+				Code:    messages.JsonRPCErrorInvalidParams,
+				Message: fmt.Sprintf("invalid logging level %q", level),
+			},
+		}
+	}
+
+	sessionFromContext(ctx).setLogLevel(level)
+	s.logger.Debug("Session %q set logging level to %s", request.SessionID, level)
+	return &messages.JsonRPCResult{}, nil
+}
+
+// ClientLogger writes to the server's local logger and forwards records at or above the client's level as notifications/message
+type ClientLogger struct {
+	server  *DefaultServer
+	session *session
+	ctx     context.Context
+	name    string
+}
+
+// LoggerFromContext returns a logger for the request being handled, or nil outside a request; a nil logger discards everything.
+// The logger outlives the request, so work the handler leaves running in the background can keep logging to the client.
+func LoggerFromContext(ctx context.Context) *ClientLogger {
+	s := serverFromContext(ctx)
+	current := sessionFromContext(ctx)
+	if s == nil || current == nil {
+		return nil
+	}
+	return &ClientLogger{server: s, session: current, ctx: context.WithoutCancel(ctx), name: s.Name}
+}
+
+// Named returns a copy of the logger reporting under another logger name
+func (l *ClientLogger) Named(name string) *ClientLogger {
+	if l == nil {
+		return nil
+	}
+
+	named := *l
+	named.name = name
+	return &named
+}
+
+// Log sends structured data, anything that marshals to JSON is accepted
+func (l *ClientLogger) Log(level LoggingLevel, data interface{}) {
+	if l == nil {
+		return
+	}
+
+	if l.name == l.server.Name {
+		l.server.logger.log(level.localLevel(), "%v", data)
+	} else {
+		l.server.logger.log(level.localLevel(), "[%s] %v", l.name, data)
+	}
+
+	if l.server.capabilities.Logging == nil {
+		return
+	}
+	threshold, selected := l.session.logLevel()
+	if !selected || level.severity() < threshold.severity() {
+		return
+	}
+
+	notification := messages.NewLoggingMessageNotification(string(level), l.name, data)
+	if err := l.server.sendNotification(l.ctx, l.session.id, notification); err != nil {
+		l.server.logger.Debug("Failed to forward log message to session %q: %v", l.session.id, err)
+	}
+}
+
+func (l *ClientLogger) logf(level LoggingLevel, msg string, args ...interface{}) {
+	if l == nil {
+		return
+	}
+
+	formattedMsg := msg
+	if len(args) > 0 {
+		formattedMsg = fmt.Sprintf(msg, args...)
+	}
+	l.Log(level, formattedMsg)
+}
+
+func (l *ClientLogger) Debug(msg string, args ...interface{}) {
+	l.logf(LoggingDebug, msg, args...)
+}
+
+func (l *ClientLogger) Info(msg string, args ...interface{}) {
+	l.logf(LoggingInfo, msg, args...)
+}
+
+func (l *ClientLogger) Notice(msg string, args ...interface{}) {
+	l.logf(LoggingNotice, msg, args...)
+}
+
+func (l *ClientLogger) Warning(msg string, args ...interface{}) {
+	l.logf(LoggingWarning, msg, args...)
+}
+
+func (l *ClientLogger) Error(msg string, args ...interface{}) {
+	l.logf(LoggingError, msg, args...)
+}
+
+func (l *ClientLogger) Critical(msg string, args ...interface{}) {
+	l.logf(LoggingCritical, msg, args...)
+}
+
+func (l *ClientLogger) Alert(msg string, args ...interface{}) {
+	l.logf(LoggingAlert, msg, args...)
+}
+
+func (l *ClientLogger) Emergency(msg string, args ...interface{}) {
+	l.logf(LoggingEmergency, msg, args...)
+}
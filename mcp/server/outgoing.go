@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+)
+
+var ErrRequestTimeout = errors.New("request timed out")
+var ErrSessionNotReady = errors.New("session not ready")
+
+type PendingRequestMap map[interface{}]pendingRequest
+
+// pendingRequest remembers which session a request went to, only that session may answer it
+type pendingRequest struct {
+	sessionID string
+	response  chan messages.JsonRPCMessage
+}
+
+// SendRequest issues a request to the client of the request being handled in ctx and waits for its response
+func (s *DefaultServer) SendRequest(ctx context.Context, method string, params *messages.JsonRPCParams) (*messages.JsonRPCResult, error) {
+	var sessionID string
+	if current := sessionFromContext(ctx); current != nil {
+		sessionID = current.id
+	}
+	return s.SendRequestToSession(ctx, sessionID, method, params)
+}
+
+// SendRequestToSession issues a request to the client of a session, only pings are allowed before the session is ready
+func (s *DefaultServer) SendRequestToSession(ctx context.Context, sessionID string, method string, params *messages.JsonRPCParams) (*messages.JsonRPCResult, error) {
+	current := s.lookupSession(sessionID)
+	if current == nil {
+		return nil, fmt.Errorf("failed to send request %s to session %q: %w", method, sessionID, ErrSessionNotFound)
+	}
+	if method != "ping" {
+		if state := current.state(); state != SessionReady {
+			return nil, fmt.Errorf("%w: session %q is %s", ErrSessionNotReady, sessionID, state)
+		}
+	}
+	return s.sendRequest(ctx, sessionID, method, params)
+}
+
+// sendRequest issues a server-to-client request to a session and waits for the matching response
+func (s *DefaultServer) sendRequest(ctx context.Context, sessionID string, method string, params *messages.JsonRPCParams) (*messages.JsonRPCResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.RequestTimeoutSeconds*time.Second)
+	defer cancel()
+
+	request := messages.Request{
+		JsonRPC:   "2.0",
+		ID:        s.nextRequestID.Add(1),
+		Method:    method,
+		Params:    params,
+		SessionID: sessionID,
+	}
+
+	responseCh := make(chan messages.JsonRPCMessage, 1)
+	key := messages.NormalizeID(request.ID)
+	s.pendingMutex.Lock()
+	s.pendingRequests[key] = pendingRequest{sessionID: sessionID, response: responseCh}
+	s.pendingMutex.Unlock()
+
+	defer func() {
+		s.pendingMutex.Lock()
+		delete(s.pendingRequests, key)
+		s.pendingMutex.Unlock()
+	}()
+
+	msg := request.ToJsonRPCMessage()
+	msg.RelatedRequestID = relatedRequestID(ctx, sessionID)
+
+	writeCtx, cancelWrite := context.WithTimeout(ctx, s.config.OutgoingMessageTimeoutSeconds*time.Second)
+	err := s.transport.Write(*msg, writeCtx)
+	cancelWrite()
+	if err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			s.removeSession(sessionID)
+		}
+		return nil, fmt.Errorf("failed to send request %s: %w", method, err)
+	}
+
+	select {
+	case response := <-responseCh:
+		if response.Error != nil {
+			return nil, response.Error
+		}
+		if response.Result == nil {
+			return &messages.JsonRPCResult{}, nil
+		}
+		return response.Result, nil
+	case <-ctx.Done():
+		// The context is already done, the client still has to hear that we gave up
+		go s.notifyRequestCancelled(context.WithoutCancel(ctx), sessionID, request.ID, ctx.Err().Error())
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: %s", ErrRequestTimeout, method)
+		}
+		return nil, fmt.Errorf("request %s cancelled: %w", method, ctx.Err())
+	case <-s.closeSignalChan:
+		return nil, errors.New("server closed")
+	}
+}
+
+func (s *DefaultServer) notifyRequestCancelled(ctx context.Context, sessionID string, requestID interface{}, reason string) {
+	notification := messages.NewCancellationNotification(requestID, reason)
+	if err := s.sendNotification(ctx, sessionID, notification); err != nil {
+		s.logger.Debug("Failed to send cancellation of request %v: %v", requestID, err)
+	}
+}
+
+func (s *DefaultServer) handleResponse(msg *messages.JsonRPCMessage) {
+	// Objects and arrays can't be map keys, and the server never issues such IDs anyway
+	if !messages.IsValidID(msg.ID) {
+		s.logger.Warn("Dropping response with invalid ID type %T", msg.ID)
+		return
+	}
+
+	key := messages.NormalizeID(msg.ID)
+	s.pendingMutex.Lock()
+	pending, exist := s.pendingRequests[key]
+	if exist && pending.sessionID != msg.SessionID {
+		s.pendingMutex.Unlock()
+		s.logger.Warn("Ignoring response to request %v from session %q, it was sent to session %q", msg.ID, msg.SessionID, pending.sessionID)
+		return
+	}
+	delete(s.pendingRequests, key)
+	s.pendingMutex.Unlock()
+
+	if !exist {
+		s.logger.Warn("Received response for unknown request ID: %v", msg.ID)
+		return
+	}
+	pending.response <- *msg
+}
+
+func (s *DefaultServer) handlePingRequest(ctx context.Context, request messages.Request) (*messages.JsonRPCResult, *RequestError) {
+	result := messages.JsonRPCResult(messages.NewPingResponse(request.ID).Result)
+	return &result, nil
+}
+
+// OnPingTimeout registers a hook that runs when a session misses a ping, before the session is closed
+func (s *DefaultServer) OnPingTimeout(hook func(SessionInfo)) {
+	s.hooksMutex.Lock()
+	defer s.hooksMutex.Unlock()
+
+	s.pingTimeoutHooks = append(s.pingTimeoutHooks, hook)
+}
+
+// closeSession drops a session whose peer stopped answering, a transport that cannot close sessions carries only that peer so the server is closed
+func (s *DefaultServer) closeSession(sessionID string) {
+	if current := s.lookupSession(sessionID); current != nil {
+		s.hooksMutex.RLock()
+		hooks := append([]func(SessionInfo){}, s.pingTimeoutHooks...)
+		s.hooksMutex.RUnlock()
+
+		info := current.sessionInfo()
+		for _, hook := range hooks {
+			hook(info)
+		}
+	}
+
+	s.removeSession(sessionID)
+	closer, ok := s.transport.(SessionCloser)
+	if !ok {
+		s.logger.Warn("Transport cannot close session %q, closing server", sessionID)
+		s.Close()
+		return
+	}
+
+	if err := closer.CloseSession(sessionID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		s.logger.Error("Failed to close session %q: %v", sessionID, err)
+	}
+}
+
+func (s *DefaultServer) pingSession(ctx context.Context, sessionID string) {
+	withTimeoutCtx, cancel := context.WithTimeout(ctx, s.config.PingTimeoutSeconds*time.Second)
+	defer cancel()
+
+	_, err := s.sendRequest(withTimeoutCtx, sessionID, "ping", nil)
+	if errors.Is(err, ErrRequestTimeout) {
+		s.logger.Warn("Ping to session %q timed out, closing session", sessionID)
+		s.closeSession(sessionID)
+	} else if err != nil {
+		s.logger.Debug("Ping to session %q failed: %v", sessionID, err)
+	}
+}
+
+func (s *DefaultServer) pingLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PingIntervalSeconds * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.closeSignalChan:
+			return
+		case <-ticker.C:
+			for _, current := range s.allSessions() {
+				// A client slower than the interval would otherwise pile up pings that each time out on their own
+				if !current.beginPing() {
+					continue
+				}
+				go func() {
+					defer current.endPing()
+					s.pingSession(ctx, current.id)
+				}()
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package server_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+// closingMemoryTransport is a memoryTransport that can close sessions, like the HTTP transports
+type closingMemoryTransport struct {
+	*memoryTransport
+	closed chan string
+}
+
+func (c *closingMemoryTransport) CloseSession(sessionID string) error {
+	c.closed <- sessionID
+	return nil
+}
+
+// startPingServer pings every second and returns once the session is ready
+func startPingServer(t *testing.T, timeoutSeconds time.Duration) (*server.DefaultServer, *memoryTransport) {
+	t.Helper()
+	transport := newMemoryTransport()
+	return startPingServerOn(t, transport, transport, timeoutSeconds)
+}
+
+// startPingServerOn runs a pinging server on transport, memory is the memoryTransport underneath it
+func startPingServerOn(t *testing.T, transport server.Transport, memory *memoryTransport, timeoutSeconds time.Duration) (*server.DefaultServer, *memoryTransport) {
+	t.Helper()
+
+	var s *server.DefaultServer
+	config := server.NewDefaultConfig()
+	config.LogLevel = server.LogError
+	config.PingIntervalSeconds = 1
+	config.PingTimeoutSeconds = timeoutSeconds
+	runServer(t, transport, config, func(created *server.DefaultServer) *server.DefaultServer {
+		s = created
+		return s
+	})
+	initializeMemorySession(t, memory, `{}`)
+	return s, memory
+}
+
+func receivePing(t *testing.T, transport *memoryTransport) messages.JsonRPCMessage {
+	t.Helper()
+
+	ping := transport.receive(t)
+	if ping.Method == nil || *ping.Method != "ping" {
+		t.Fatalf("expected ping from the server, got %+v", ping)
+	}
+	return ping
+}
+
+// expectServerClosed pings the server until it stops answering
+func expectServerClosed(t *testing.T, transport *memoryTransport) {
+	t.Helper()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for id := 1; time.Now().Before(deadline); id++ {
+		transport.send(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"ping"}`, id))
+		if !receiveResponse(transport, id, 500*time.Millisecond) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("expected the server to stop answering")
+}
+
+// receiveResponse reports whether the response to id arrives in time, skipping the server's own pings
+func receiveResponse(transport *memoryTransport, id int, timeout time.Duration) bool {
+	expired := time.After(timeout)
+	for {
+		select {
+		case msg := <-transport.out:
+			if msg.IsResponse() && msg.ID == float64(id) {
+				return true
+			}
+		case <-expired:
+			return false
+		}
+	}
+}
+
+func TestPingRequest(t *testing.T) {
+	transport := startMemoryServer(t, nil)
+
+	transport.send(t, `{"jsonrpc":"2.0","id":"p","method":"ping"}`)
+	response := transport.receive(t)
+	if response.ID != "p" || response.Error != nil || response.Result == nil || len(*response.Result) != 0 {
+		t.Errorf("expected an empty ping result, got %+v", response)
+	}
+}
+
+func TestPingAnswered(t *testing.T) {
+	// A zero timeout falls back to the default instead of timing out every ping at once
+	s, transport := startPingServer(t, 0)
+
+	ping := receivePing(t, transport)
+	transport.send(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":%v,"result":{}}`, ping.ID))
+
+	receivePing(t, transport)
+	sessions := s.Sessions()
+	if len(sessions) != 1 || sessions[0].State != server.SessionReady {
+		t.Errorf("expected the session to stay ready after answering, got %+v", sessions)
+	}
+}
+
+func TestPingMissed(t *testing.T) {
+	s, transport := startPingServer(t, 1)
+	missed := make(chan server.SessionInfo, 1)
+	s.OnPingTimeout(func(info server.SessionInfo) {
+		missed <- info
+	})
+
+	receivePing(t, transport)
+	select {
+	case info := <-missed:
+		if info.ID != "" || info.State != server.SessionReady {
+			t.Errorf("expected the hook to see the ready session, got %+v", info)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("expected the ping timeout hook to run")
+	}
+
+	// Stdio-like transports only carry the one peer, so the server stops instead of serving a dead connection
+	expectServerClosed(t, transport)
+	if sessions := s.Sessions(); len(sessions) != 0 {
+		t.Errorf("expected the unresponsive session to be dropped, got %+v", sessions)
+	}
+}
+
+func TestPingMissedClosesSession(t *testing.T) {
+	memory := newMemoryTransport()
+	transport := &closingMemoryTransport{memoryTransport: memory, closed: make(chan string, 1)}
+	s, _ := startPingServerOn(t, transport, memory, 1)
+
+	receivePing(t, memory)
+	select {
+	case sessionID := <-transport.closed:
+		if sessionID != "" {
+			t.Errorf("expected the unresponsive session to be closed, got %q", sessionID)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("expected the transport to close the unresponsive session")
+	}
+	if sessions := s.Sessions(); len(sessions) != 0 {
+		t.Errorf("expected the closed session to be dropped, got %+v", sessions)
+	}
+}
+
+func TestPingSkipsSessionWithPingInFlight(t *testing.T) {
+	_, transport := startPingServer(t, 3)
+
+	ping := receivePing(t, transport)
+	select {
+	case msg := <-transport.out:
+		t.Fatalf("expected no ping while the last one is unanswered, got %+v", msg)
+	case <-time.After(1500 * time.Millisecond):
+	}
+
+	transport.send(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":%v,"result":{}}`, ping.ID))
+	receivePing(t, transport)
+}
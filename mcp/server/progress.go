@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+)
+
+var ErrProgressNotIncreasing = errors.New("progress must increase with every notification")
+
+type ctxProgressKey struct{}
+
+// ProgressReporter sends notifications/progress for a request whose client asked for progress, a nil reporter does nothing
+type ProgressReporter struct {
+	server      *DefaultServer
+	sessionID   string
+	token       interface{}
+	minInterval time.Duration
+	last        float32
+	reported    bool
+	lastSent    time.Time
+	pending     *messages.Notification // Last update held back by rate limiting
+	pendingCtx  context.Context
+	timer       *time.Timer // Sends pending once the interval has passed
+	mutex       sync.Mutex
+	sendMutex   sync.Mutex // Keeps the timer, Report and flush from sending out of order
+}
+
+func (s *DefaultServer) newProgressReporter(sessionID string, token interface{}) *ProgressReporter {
+	return &ProgressReporter{
+		server:      s,
+		sessionID:   sessionID,
+		token:       token,
+		minInterval: s.config.ProgressIntervalMilliseconds * time.Millisecond,
+	}
+}
+
+// ProgressFromContext returns the reporter of the request being handled, or nil when the client did not ask for progress
+func ProgressFromContext(ctx context.Context) *ProgressReporter {
+	reporter, _ := ctx.Value(ctxProgressKey{}).(*ProgressReporter)
+	return reporter
+}
+
+// Report sends progress out of an optional total, updates arriving faster than the configured interval are held back
+// and only the last of them is sent once the interval has passed or the request finishes
+func (p *ProgressReporter) Report(ctx context.Context, progress float32, total float32, message string) error {
+	if p == nil {
+		return nil
+	}
+
+	p.mutex.Lock()
+	if p.reported && progress <= p.last {
+		p.mutex.Unlock()
+		return ErrProgressNotIncreasing
+	}
+	p.last = progress
+	p.reported = true
+
+	notification := messages.NewProgressNotification(p.token, progress, total, message)
+	final := total > 0 && progress >= total
+	now := time.Now()
+	if !final && !p.lastSent.IsZero() && now.Sub(p.lastSent) < p.minInterval {
+		p.pending = notification
+		p.pendingCtx = ctx
+		if p.timer == nil {
+			p.timer = time.AfterFunc(p.minInterval-now.Sub(p.lastSent), p.sendPending)
+		}
+		p.mutex.Unlock()
+		return nil
+	}
+	p.lastSent = now
+	p.pending = nil
+	p.stopTimer()
+	p.mutex.Unlock()
+
+	p.sendMutex.Lock()
+	defer p.sendMutex.Unlock()
+	return p.server.sendNotification(ctx, p.sessionID, notification)
+}
+
+// stopTimer cancels a scheduled send of the pending update, the caller holds p.mutex
+func (p *ProgressReporter) stopTimer() {
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+}
+
+// sendPending sends the update held back once its interval has passed, the request may not finish for a long time
+func (p *ProgressReporter) sendPending() {
+	p.sendMutex.Lock()
+	defer p.sendMutex.Unlock()
+
+	p.mutex.Lock()
+	p.timer = nil
+	notification, ctx := p.pending, p.pendingCtx
+	p.pending = nil
+	p.pendingCtx = nil
+	if notification != nil {
+		p.lastSent = time.Now()
+	}
+	p.mutex.Unlock()
+	if notification == nil {
+		return
+	}
+
+	if err := p.server.sendNotification(ctx, p.sessionID, notification); err != nil {
+		p.server.logger.Debug("Failed to send held back progress of session %q: %v", p.sessionID, err)
+	}
+}
+
+// flush sends the update held back last, without a total the reporter can't tell which update was the final one
+func (p *ProgressReporter) flush(ctx context.Context) {
+	if p == nil {
+		return
+	}
+
+	p.sendMutex.Lock()
+	defer p.sendMutex.Unlock()
+
+	p.mutex.Lock()
+	p.stopTimer()
+	notification := p.pending
+	p.pending = nil
+	p.pendingCtx = nil
+	p.mutex.Unlock()
+	if notification == nil {
+		return
+	}
+
+	if err := p.server.sendNotification(ctx, p.sessionID, notification); err != nil {
+		p.server.logger.Debug("Failed to send final progress of session %q: %v", p.sessionID, err)
+	}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+var (
+	ErrPromptNotFound         = errors.New("prompt not found")
+	ErrMissingPromptArguments = errors.New("missing required arguments")
+)
+
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptContent accepts the same content blocks as tool results
+type PromptContent = ToolCallContent
+
+type PromptMessage struct {
+	Role    string        `json:"role"`
+	Content PromptContent `json:"content"`
+}
+
+type PromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+func NewTextPromptMessage(role string, text string) PromptMessage {
+	return PromptMessage{
+		Role: role,
+		Content: PromptContent{
+			Type: "text",
+			Text: &text,
+		},
+	}
+}
+
+func NewImagePromptMessage(role string, data string, mimeType string) PromptMessage {
+	return PromptMessage{
+		Role: role,
+		Content: PromptContent{
+			Type:     "image",
+			Data:     &data,
+			MimeType: &mimeType,
+		},
+	}
+}
+
+func NewResourcePromptMessage(role string, resource ResourceContents) PromptMessage {
+	return PromptMessage{
+		Role: role,
+		Content: PromptContent{
+			Type:     "resource",
+			Resource: &resource,
+		},
+	}
+}
+
+type PromptCallback func(context.Context, string, map[string]string) (PromptResult, error)
+type PromptCallbacksMap map[string]PromptCallback
+
+type PromptManager struct {
+	prompts         []Prompt
+	promptCallbacks PromptCallbacksMap
+	completions     map[string]CompletionProvidersMap // Keyed by prompt name
+}
+
+func (p *PromptManager) findPrompt(name string) (*Prompt, PromptCallback, error) {
+	for i := range p.prompts {
+		if p.prompts[i].Name == name {
+			callback, exist := p.promptCallbacks[name]
+			if !exist {
+				return nil, nil, errors.New("can't render prompt, no callback")
+			}
+			return &p.prompts[i], callback, nil
+		}
+	}
+
+	return nil, nil, ErrPromptNotFound
+}
+
+func (p *PromptManager) AddPrompt(definition Prompt, callback PromptCallback) {
+	p.prompts = append(p.prompts, definition)
+	p.promptCallbacks[definition.Name] = callback
+}
+
+func (p *PromptManager) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*PromptResult, error) {
+	prompt, callback, err := p.findPrompt(name)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make([]string, 0)
+	for _, argument := range prompt.Arguments {
+		if _, exist := arguments[argument.Name]; argument.Required && !exist {
+			missing = append(missing, argument.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingPromptArguments, strings.Join(missing, ", "))
+	}
+
+	result, err := callback(ctx, prompt.Name, arguments)
+	if err != nil {
+		return nil, err
+	}
+	if result.Messages == nil {
+		result.Messages = make([]PromptMessage, 0)
+	}
+	return &result, nil
+}
+
+// AddArgumentCompletion registers a completion provider for an argument the prompt declares
+func (p *PromptManager) AddArgumentCompletion(promptName string, argumentName string, provider CompletionProvider) error {
+	prompt, _, err := p.findPrompt(promptName)
+	if err != nil {
+		return err
+	}
+
+	for _, argument := range prompt.Arguments {
+		if argument.Name == argumentName {
+			if p.completions[promptName] == nil {
+				p.completions[promptName] = make(CompletionProvidersMap)
+			}
+			p.completions[promptName][argumentName] = provider
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s of prompt %s", ErrUnknownCompletionArgument, argumentName, promptName)
+}
+
+func (p *PromptManager) CompletePromptArgument(ctx context.Context, promptName string, argumentName string, value string, arguments map[string]string) (CompletionResult, error) {
+	if _, _, err := p.findPrompt(promptName); err != nil {
+		return CompletionResult{}, err
+	}
+
+	return complete(ctx, p.completions[promptName], argumentName, value, arguments)
+}
+
+func (p *PromptManager) ListAllPrompts() *[]Prompt {
+	return &p.prompts
+}
+
+func NewPromptManager() PromptManager {
+	return PromptManager{
+		prompts:         make([]Prompt, 0),
+		promptCallbacks: make(PromptCallbacksMap),
+		completions:     make(map[string]CompletionProvidersMap),
+	}
+}
@@ -0,0 +1,76 @@
+package server_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+func TestPrompts(t *testing.T) {
+	promptManager := server.NewPromptManager()
+	promptManager.AddPrompt(server.Prompt{
+		Name:        "review",
+		Description: "Review a change",
+		Arguments: []server.PromptArgument{
+			{Name: "diff", Required: true},
+			{Name: "tone"},
+		},
+	}, func(ctx context.Context, name string, arguments map[string]string) (server.PromptResult, error) {
+		return server.PromptResult{
+			Description: "Review in a " + arguments["tone"] + " tone",
+			Messages:    []server.PromptMessage{server.NewTextPromptMessage(server.RoleUser, "Review this: "+arguments["diff"])},
+		}, nil
+	})
+
+	transport := startMemoryServer(t, func(s *server.DefaultServer) *server.DefaultServer {
+		s = server.WithPromptsCapability(s, false, false)
+		return server.WithPromptManager(s, &promptManager)
+	})
+	initializeMemorySession(t, transport, `{}`)
+
+	transport.send(t, `{"jsonrpc":"2.0","id":1,"method":"prompts/list"}`)
+	response := transport.receive(t)
+	prompts, _ := (*response.Result)["prompts"].([]interface{})
+	if len(prompts) != 1 {
+		t.Fatalf("expected one prompt, got %+v", *response.Result)
+	}
+	prompt := prompts[0].(map[string]interface{})
+	arguments, _ := prompt["arguments"].([]interface{})
+	if prompt["name"] != "review" || len(arguments) != 2 || arguments[0].(map[string]interface{})["required"] != true {
+		t.Errorf("unexpected prompt definition %+v", prompt)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":2,"method":"prompts/get","params":{"name":"review","arguments":{"diff":"+1 line","tone":"friendly"}}}`)
+	response = transport.receive(t)
+	if response.Result == nil {
+		t.Fatalf("failed to get prompt: %+v", response.Error)
+	}
+	promptMessages, _ := (*response.Result)["messages"].([]interface{})
+	if len(promptMessages) != 1 || (*response.Result)["description"] != "Review in a friendly tone" {
+		t.Fatalf("unexpected prompt result %+v", *response.Result)
+	}
+	message := promptMessages[0].(map[string]interface{})
+	content, _ := message["content"].(map[string]interface{})
+	if message["role"] != "user" || content["type"] != "text" || content["text"] != "Review this: +1 line" {
+		t.Errorf("unexpected prompt message %+v", message)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":3,"method":"prompts/get","params":{"name":"review","arguments":{"tone":"terse"}}}`)
+	response = transport.receive(t)
+	if response.Error == nil || response.Error.Code != messages.JsonRPCErrorInvalidParams || !strings.Contains(response.Error.Message, "diff") {
+		t.Errorf("expected missing required argument to be invalid params naming it, got %+v", response)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":4,"method":"prompts/get","params":{"name":"unknown"}}`)
+	if response := transport.receive(t); response.Error == nil || response.Error.Code != messages.JsonRPCErrorInvalidParams {
+		t.Errorf("expected unknown prompt to be invalid params, got %+v", response)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":5,"method":"prompts/get","params":{"name":"review","arguments":{"diff":42}}}`)
+	if response := transport.receive(t); response.Error == nil || response.Error.Code != messages.JsonRPCErrorInvalidParams {
+		t.Errorf("expected non-string argument to be invalid params, got %+v", response)
+	}
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrResourceNotFound = errors.New("resource not found")
+
+type Resource struct {
+	URI         string      `json:"uri"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	MimeType    string      `json:"mimeType,omitempty"`
+	Annotations interface{} `json:"annotations,omitempty"`
+}
+
+type ResourceTemplate struct {
+	URITemplate string      `json:"uriTemplate"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	MimeType    string      `json:"mimeType,omitempty"`
+	Annotations interface{} `json:"annotations,omitempty"`
+}
+
+type ResourceContents struct {
+	URI      string  `json:"uri"`
+	MimeType string  `json:"mimeType,omitempty"`
+	Text     *string `json:"text,omitempty"`
+	Blob     *string `json:"blob,omitempty"` // base64-encoded string
+}
+
+func NewTextResourceContents(uri string, mimeType string, text string) ResourceContents {
+	return ResourceContents{
+		URI:      uri,
+		MimeType: mimeType,
+		Text:     &text,
+	}
+}
+
+func NewBlobResourceContents(uri string, mimeType string, data []byte) ResourceContents {
+	blob := base64.StdEncoding.EncodeToString(data)
+	return ResourceContents{
+		URI:      uri,
+		MimeType: mimeType,
+		Blob:     &blob,
+	}
+}
+
+type ResourceCallback func(context.Context, string) ([]ResourceContents, error)
+type ResourceCallbacksMap map[string]ResourceCallback
+
+// ResourceTemplateCallback receives the variables extracted from the requested URI
+type ResourceTemplateCallback func(context.Context, string, map[string]string) ([]ResourceContents, error)
+
+type resourceTemplateEntry struct {
+	template *URITemplate
+	callback ResourceTemplateCallback
+}
+
+type ResourceManager struct {
+	resources         []Resource
+	resourceCallbacks ResourceCallbacksMap
+	resourceTemplates []ResourceTemplate
+	templateEntries   []resourceTemplateEntry           // Sorted from the most to the least specific template
+	completions       map[string]CompletionProvidersMap // Keyed by URI template
+}
+
+func (r *ResourceManager) AddResource(definition Resource, callback ResourceCallback) {
+	r.resources = append(r.resources, definition)
+	r.resourceCallbacks[definition.URI] = callback
+}
+
+func (r *ResourceManager) AddResourceTemplate(definition ResourceTemplate, callback ResourceTemplateCallback) error {
+	template, err := ParseURITemplate(definition.URITemplate)
+	if err != nil {
+		return err
+	}
+
+	r.resourceTemplates = append(r.resourceTemplates, definition)
+	r.templateEntries = append(r.templateEntries, resourceTemplateEntry{
+		template: template,
+		callback: callback,
+	})
+
+	// Stable so that equally specific templates keep their registration order
+	sort.SliceStable(r.templateEntries, func(i, j int) bool {
+		return r.templateEntries[i].template.moreSpecificThan(r.templateEntries[j].template)
+	})
+
+	return nil
+}
+
+// ReadResource serves static resources first and falls back to the most specific matching template
+func (r *ResourceManager) ReadResource(ctx context.Context, uri string) ([]ResourceContents, error) {
+	if callback, exist := r.resourceCallbacks[uri]; exist {
+		return callback(ctx, uri)
+	}
+
+	for _, entry := range r.templateEntries {
+		if variables, ok := entry.template.Match(uri); ok {
+			return entry.callback(ctx, uri, variables)
+		}
+	}
+
+	return nil, ErrResourceNotFound
+}
+
+func (r *ResourceManager) findTemplate(uriTemplate string) (*URITemplate, error) {
+	for _, entry := range r.templateEntries {
+		if entry.template.String() == uriTemplate {
+			return entry.template, nil
+		}
+	}
+	return nil, ErrResourceNotFound
+}
+
+// AddTemplateCompletion registers a completion provider for a variable of a registered URI template
+func (r *ResourceManager) AddTemplateCompletion(uriTemplate string, variable string, provider CompletionProvider) error {
+	template, err := r.findTemplate(uriTemplate)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range template.Variables() {
+		if name == variable {
+			if r.completions[uriTemplate] == nil {
+				r.completions[uriTemplate] = make(CompletionProvidersMap)
+			}
+			r.completions[uriTemplate][variable] = provider
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s of template %s", ErrUnknownCompletionArgument, variable, uriTemplate)
+}
+
+func (r *ResourceManager) CompleteTemplateVariable(ctx context.Context, uriTemplate string, variable string, value string, arguments map[string]string) (CompletionResult, error) {
+	if _, err := r.findTemplate(uriTemplate); err != nil {
+		return CompletionResult{}, err
+	}
+
+	return complete(ctx, r.completions[uriTemplate], variable, value, arguments)
+}
+
+func (r *ResourceManager) ListAllResources() *[]Resource {
+	return &r.resources
+}
+
+func (r *ResourceManager) ListAllResourceTemplates() *[]ResourceTemplate {
+	return &r.resourceTemplates
+}
+
+func NewResourceManager() ResourceManager {
+	return ResourceManager{
+		resources:         make([]Resource, 0),
+		resourceCallbacks: make(ResourceCallbacksMap),
+		resourceTemplates: make([]ResourceTemplate, 0),
+		templateEntries:   make([]resourceTemplateEntry, 0),
+		completions:       make(map[string]CompletionProvidersMap),
+	}
+}
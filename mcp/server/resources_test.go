@@ -0,0 +1,154 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+func TestResources(t *testing.T) {
+	resourceManager := server.NewResourceManager()
+	resourceManager.AddResource(server.Resource{
+		URI:      "file:///readme.md",
+		Name:     "README",
+		MimeType: "text/markdown",
+	}, func(ctx context.Context, uri string) ([]server.ResourceContents, error) {
+		return []server.ResourceContents{server.NewTextResourceContents(uri, "text/markdown", "# Hello")}, nil
+	})
+	resourceManager.AddResource(server.Resource{
+		URI:  "file:///broken",
+		Name: "Broken",
+	}, func(ctx context.Context, uri string) ([]server.ResourceContents, error) {
+		return nil, errors.New("disk unavailable")
+	})
+	if err := resourceManager.AddResourceTemplate(server.ResourceTemplate{
+		URITemplate: "users://{id}/profile",
+		Name:        "User profile",
+	}, func(ctx context.Context, uri string, variables map[string]string) ([]server.ResourceContents, error) {
+		return []server.ResourceContents{server.NewTextResourceContents(uri, "text/plain", "user "+variables["id"])}, nil
+	}); err != nil {
+		t.Fatalf("failed to add resource template: %v", err)
+	}
+
+	transport := startMemoryServer(t, func(s *server.DefaultServer) *server.DefaultServer {
+		s = server.WithResourcesCapability(s, false, false)
+		return server.WithResourceManager(s, &resourceManager)
+	})
+	initializeMemorySession(t, transport, `{}`)
+
+	transport.send(t, `{"jsonrpc":"2.0","id":1,"method":"resources/list"}`)
+	response := transport.receive(t)
+	resources, _ := (*response.Result)["resources"].([]interface{})
+	if len(resources) != 2 || resources[0].(map[string]interface{})["uri"] != "file:///readme.md" {
+		t.Errorf("expected both static resources, got %+v", *response.Result)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":2,"method":"resources/templates/list"}`)
+	response = transport.receive(t)
+	templates, _ := (*response.Result)["resourceTemplates"].([]interface{})
+	if len(templates) != 1 || templates[0].(map[string]interface{})["uriTemplate"] != "users://{id}/profile" {
+		t.Errorf("expected the resource template, got %+v", *response.Result)
+	}
+
+	readText := func(id int, uri string) string {
+		t.Helper()
+
+		transport.send(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"resources/read","params":{"uri":%q}}`, id, uri))
+		response := transport.receive(t)
+		if response.Result == nil {
+			t.Fatalf("failed to read %s: %+v", uri, response.Error)
+		}
+		contents, _ := (*response.Result)["contents"].([]interface{})
+		if len(contents) != 1 {
+			t.Fatalf("expected one content item for %s, got %+v", uri, *response.Result)
+		}
+		text, _ := contents[0].(map[string]interface{})["text"].(string)
+		return text
+	}
+	if text := readText(3, "file:///readme.md"); text != "# Hello" {
+		t.Errorf("expected static resource text, got %q", text)
+	}
+	if text := readText(4, "users://42/profile"); text != "user 42" {
+		t.Errorf("expected templated resource text, got %q", text)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":5,"method":"resources/read","params":{"uri":"file:///missing"}}`)
+	response = transport.receive(t)
+	if response.Error == nil || response.Error.Code != messages.JsonRPCErrorResourceNotFound {
+		t.Fatalf("expected resource not found error, got %+v", response)
+	}
+	if data, _ := (*response.Error.Data).(map[string]interface{}); data["uri"] != "file:///missing" {
+		t.Errorf("expected the unknown uri in the error data, got %+v", response.Error.Data)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":6,"method":"resources/read","params":{"uri":"file:///broken"}}`)
+	if response := transport.receive(t); response.Error == nil || response.Error.Code != messages.JsonRPCErrorInternalError {
+		t.Errorf("expected failing callback to be an internal error, got %+v", response)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":7,"method":"resources/read","params":{}}`)
+	if response := transport.receive(t); response.Error == nil || response.Error.Code != messages.JsonRPCErrorInvalidParams {
+		t.Errorf("expected missing uri to be invalid params, got %+v", response)
+	}
+}
+
+func TestResourceSubscriptions(t *testing.T) {
+	resourceManager := server.NewResourceManager()
+	resourceManager.AddResource(server.Resource{URI: "file:///status", Name: "Status"}, func(ctx context.Context, uri string) ([]server.ResourceContents, error) {
+		return []server.ResourceContents{server.NewTextResourceContents(uri, "text/plain", "ok")}, nil
+	})
+
+	var s *server.DefaultServer
+	transport := startMemoryServer(t, func(created *server.DefaultServer) *server.DefaultServer {
+		s = server.WithResourcesCapability(created, false, true)
+		return server.WithResourceManager(s, &resourceManager)
+	})
+	initializeMemorySession(t, transport, `{}`)
+
+	// A ping answered before anything else shows that no notification was sent
+	expectNoNotification := func(id int) {
+		t.Helper()
+
+		transport.send(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"ping"}`, id))
+		if response := transport.receive(t); response.Method != nil || response.ID != float64(id) {
+			t.Errorf("expected no notification, got %+v", response)
+		}
+	}
+
+	if err := s.NotifyResourceUpdated("file:///status"); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+	expectNoNotification(1)
+
+	transport.send(t, `{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"file:///status"}}`)
+	if response := transport.receive(t); response.Error != nil {
+		t.Fatalf("failed to subscribe: %+v", response.Error)
+	}
+
+	if err := s.NotifyResourceUpdated("file:///status"); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+	notification := transport.receive(t)
+	if notification.Method == nil || *notification.Method != "notifications/resources/updated" || (*notification.Params)["uri"] != "file:///status" {
+		t.Fatalf("expected resource updated notification, got %+v", notification)
+	}
+
+	if err := s.NotifyResourceUpdated("file:///other"); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+	expectNoNotification(3)
+
+	transport.send(t, `{"jsonrpc":"2.0","id":4,"method":"resources/unsubscribe","params":{"uri":"file:///status"}}`)
+	if response := transport.receive(t); response.Error != nil {
+		t.Fatalf("failed to unsubscribe: %+v", response.Error)
+	}
+
+	if err := s.NotifyResourceUpdated("file:///status"); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+	expectNoNotification(5)
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrRootsNotSupported = errors.New("client does not support roots")
+
+type Root struct {
+	URI  string `json:"uri"`
+	Name string `json:"name,omitempty"`
+}
+
+type listRootsResult struct {
+	Roots []Root `json:"roots"`
+}
+
+// ListRoots returns the roots of the client of the request being handled in ctx, asking the client only when nothing is cached
+func ListRoots(ctx context.Context) ([]Root, error) {
+	s := serverFromContext(ctx)
+	current := sessionFromContext(ctx)
+	if s == nil || current == nil {
+		return nil, ErrNoClientSession
+	}
+
+	if roots, cached := current.cachedRoots(); cached {
+		return roots, nil
+	}
+	return s.fetchRoots(ctx, current)
+}
+
+func (s *DefaultServer) fetchRoots(ctx context.Context, current *session) ([]Root, error) {
+	if current.sessionInfo().ClientCapabilities.Roots == nil {
+		return nil, ErrRootsNotSupported
+	}
+
+	generation := current.rootsGeneration()
+	response, err := s.SendRequestToSession(ctx, current.id, "roots/list", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roots: %w", err)
+	}
+
+	var result listRootsResult
+	if err := remarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode roots: %w", err)
+	}
+
+	current.setRoots(result.Roots, generation)
+	return result.Roots, nil
+}
+
+// OnRootsChanged registers a hook that receives the new roots after a client sends notifications/roots/list_changed
+func (s *DefaultServer) OnRootsChanged(hook func(SessionInfo, []Root)) {
+	s.hooksMutex.Lock()
+	defer s.hooksMutex.Unlock()
+
+	s.rootsChangedHooks = append(s.rootsChangedHooks, hook)
+}
+
+func (s *DefaultServer) handleRootsListChangedNotification(ctx context.Context, sessionID string) {
+	current := s.lookupSession(sessionID)
+	if current == nil {
+		s.logger.Warn("Ignoring roots change of unknown session %q", sessionID)
+		return
+	}
+	current.invalidateRoots()
+	s.logger.Debug("Roots of session %q changed", sessionID)
+
+	s.hooksMutex.RLock()
+	hooks := append([]func(SessionInfo, []Root){}, s.rootsChangedHooks...)
+	s.hooksMutex.RUnlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	go func() {
+		roots, err := s.fetchRoots(ctx, current)
+		if err != nil {
+			s.logger.Error("Failed to refresh roots of session %q: %v", sessionID, err)
+			return
+		}
+
+		info := current.sessionInfo()
+		for _, hook := range hooks {
+			hook(info, roots)
+		}
+	}()
+}
+
+// rootPath returns the local directory of a file:// root
+func rootPath(root Root) (string, bool) {
+	parsed, err := url.Parse(root.URI)
+	if err != nil || parsed.Scheme != "file" {
+		return "", false
+	}
+	return filepath.FromSlash(parsed.Path), true
+}
+
+// resolvePath makes a path absolute and follows symlinks in its longest existing ancestor, the missing rest is joined back on.
+// Components are resolved in order so that ".." after a symlink climbs out of the link target like the OS would.
+func resolvePath(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		path = workingDirectory + string(filepath.Separator) + path
+	}
+
+	resolved := filepath.VolumeName(path) + string(filepath.Separator)
+	missing := false
+	for _, component := range strings.Split(path[len(filepath.VolumeName(path)):], string(filepath.Separator)) {
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		resolved = filepath.Join(resolved, component)
+		if missing {
+			continue
+		}
+		if _, err := os.Lstat(resolved); err != nil {
+			missing = true
+			continue
+		}
+		target, err := filepath.EvalSymlinks(resolved)
+		if err != nil {
+			return "", err
+		}
+		resolved = target
+	}
+	return resolved, nil
+}
+
+// PathInRoots reports whether path is inside one of the file:// roots, symlinks are followed for the existing part of the path
+func PathInRoots(path string, roots []Root) bool {
+	target, err := resolvePath(path)
+	if err != nil {
+		return false
+	}
+
+	for _, root := range roots {
+		directory, ok := rootPath(root)
+		if !ok {
+			continue
+		}
+		base, err := resolvePath(directory)
+		if err != nil {
+			continue
+		}
+
+		relative, err := filepath.Rel(base, target)
+		if err != nil {
+			continue
+		}
+		if relative == "." || (relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package server_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+func TestPathInRoots(t *testing.T) {
+	base := t.TempDir()
+	allowed := filepath.Join(base, "project")
+	outside := filepath.Join(base, "secrets")
+	for _, dir := range []string{allowed, outside} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(allowed, "escape")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	roots := []server.Root{
+		{URI: "https://example.com/project"},
+		{URI: "file://" + filepath.ToSlash(allowed), Name: "project"},
+	}
+
+	cases := []struct {
+		path     string
+		expected bool
+	}{
+		{allowed, true},
+		{filepath.Join(allowed, "src", "main.go"), true},
+		{filepath.Join(allowed, "..", "secrets"), false},
+		{allowed + "-other", false},
+		{filepath.Join(allowed, "escape"), false},
+		{filepath.Join(allowed, "escape", "new.txt"), false},
+		{filepath.Join(allowed, "escape", "missing", "new.txt"), false},
+		{allowed + "/escape/../secrets/new.txt", false},
+		{filepath.Join(allowed, "missing", "..", "new.txt"), true},
+		{outside, false},
+	}
+	for _, c := range cases {
+		if actual := server.PathInRoots(c.path, roots); actual != c.expected {
+			t.Errorf("PathInRoots(%q) = %v, expected %v", c.path, actual, c.expected)
+		}
+	}
+}
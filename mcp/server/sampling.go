@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+)
+
+var (
+	ErrNoClientSession      = errors.New("no client session in context")
+	ErrSamplingNotSupported = errors.New("client does not support sampling")
+)
+
+const (
+	IncludeContextNone       = "none"
+	IncludeContextThisServer = "thisServer"
+	IncludeContextAllServers = "allServers"
+)
+
+type SamplingContent struct {
+	Type     string  `json:"type"`
+	Text     *string `json:"text,omitempty"`
+	Data     *string `json:"data,omitempty"` // base64-encoded string
+	MimeType *string `json:"mimeType,omitempty"`
+}
+
+type SamplingMessage struct {
+	Role    string          `json:"role"`
+	Content SamplingContent `json:"content"`
+}
+
+type ModelHint struct {
+	Name string `json:"name,omitempty"`
+}
+
+// ModelPreferences priorities range from 0 to 1, the client makes the final choice of model
+type ModelPreferences struct {
+	Hints                []ModelHint `json:"hints,omitempty"`
+	CostPriority         *float64    `json:"costPriority,omitempty"`
+	SpeedPriority        *float64    `json:"speedPriority,omitempty"`
+	IntelligencePriority *float64    `json:"intelligencePriority,omitempty"`
+}
+
+type CreateMessageRequest struct {
+	Messages         []SamplingMessage      `json:"messages"`
+	ModelPreferences *ModelPreferences      `json:"modelPreferences,omitempty"`
+	SystemPrompt     string                 `json:"systemPrompt,omitempty"`
+	IncludeContext   string                 `json:"includeContext,omitempty"`
+	Temperature      *float64               `json:"temperature,omitempty"`
+	MaxTokens        int                    `json:"maxTokens"`
+	StopSequences    []string               `json:"stopSequences,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type CreateMessageResult struct {
+	Role       string          `json:"role"`
+	Content    SamplingContent `json:"content"`
+	Model      string          `json:"model"`
+	StopReason string          `json:"stopReason,omitempty"`
+}
+
+func NewTextSamplingMessage(role string, text string) SamplingMessage {
+	return SamplingMessage{
+		Role: role,
+		Content: SamplingContent{
+			Type: "text",
+			Text: &text,
+		},
+	}
+}
+
+// CreateMessage asks the client of the request being handled in ctx to sample its LLM
+func CreateMessage(ctx context.Context, request CreateMessageRequest) (*CreateMessageResult, error) {
+	s := serverFromContext(ctx)
+	current := sessionFromContext(ctx)
+	if s == nil || current == nil {
+		return nil, ErrNoClientSession
+	}
+	if current.sessionInfo().ClientCapabilities.Sampling == nil {
+		return nil, ErrSamplingNotSupported
+	}
+	if len(request.Messages) == 0 {
+		return nil, errors.New("sampling request needs at least one message")
+	}
+	if request.MaxTokens <= 0 {
+		return nil, errors.New("sampling request needs a positive maxTokens")
+	}
+
+	var params messages.JsonRPCParams
+	if err := remarshal(request, &params); err != nil {
+		return nil, err
+	}
+
+	response, err := s.SendRequestToSession(ctx, current.id, "sampling/createMessage", &params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create message: %w", err)
+	}
+
+	var result CreateMessageResult
+	if err := remarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode sampling result: %w", err)
+	}
+	return &result, nil
+}
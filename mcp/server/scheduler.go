@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+)
+
+var ErrServerBusy = errors.New("server busy")
+
+type QueuePolicy string
+
+const (
+	// QueuePolicyWait holds requests until a worker is free, up to MaxRequestQueued of them
+	QueuePolicyWait QueuePolicy = "queue"
+	// QueuePolicyReject refuses requests as soon as every worker is busy
+	QueuePolicyReject QueuePolicy = "reject"
+)
+
+// scheduledJob is a request waiting for a worker, reject answers it when the workers stop before running it
+type scheduledJob struct {
+	run    func()
+	reject func()
+}
+
+// requestScheduler runs request handlers on a fixed pool of workers, admitting at most capacity requests at once
+type requestScheduler struct {
+	jobs     chan scheduledJob
+	workers  int
+	capacity int64
+	admitted atomic.Int64 // running and queued requests
+	active   atomic.Int64
+	stopped  atomic.Bool
+}
+
+func newRequestScheduler(config ServerConfig) *requestScheduler {
+	if config.MaxRequestActive <= 0 {
+		return &requestScheduler{}
+	}
+
+	depth := config.MaxRequestQueued
+	if config.RequestQueuePolicy == QueuePolicyReject || depth < 0 {
+		depth = 0
+	}
+
+	capacity := config.MaxRequestActive + depth
+	return &requestScheduler{
+		// Admission keeps the channel from ever filling up, so handing over a job never blocks
+		jobs:     make(chan scheduledJob, capacity),
+		workers:  config.MaxRequestActive,
+		capacity: int64(capacity),
+	}
+}
+
+// start launches the workers, they exit when ctx is done or stop is closed and the jobs still queued are rejected
+func (r *requestScheduler) start(ctx context.Context, stop <-chan int) {
+	if r.jobs == nil {
+		return
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stop:
+		}
+		// Busy workers can't drain the queue, they may be stuck in a handler
+		r.drain()
+	}()
+
+	for i := 0; i < r.workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-stop:
+					return
+				case job := <-r.jobs:
+					if r.stopped.Load() {
+						job.reject()
+					} else {
+						r.run(job.run)
+					}
+					r.admitted.Add(-1)
+				}
+			}
+		}()
+	}
+}
+
+// drain rejects every queued job, no worker is going to run them
+func (r *requestScheduler) drain() {
+	r.stopped.Store(true)
+	for {
+		select {
+		case job := <-r.jobs:
+			job.reject()
+			r.admitted.Add(-1)
+		default:
+			return
+		}
+	}
+}
+
+func (r *requestScheduler) run(job func()) {
+	r.active.Add(1)
+	defer r.active.Add(-1)
+
+	job()
+}
+
+// submit admits a job while fewer than capacity requests are running or queued, without a pool the job gets its own goroutine
+func (r *requestScheduler) submit(job func(), reject func()) error {
+	if r.jobs == nil {
+		go r.run(job)
+		return nil
+	}
+
+	for {
+		admitted := r.admitted.Load()
+		if admitted >= r.capacity {
+			return ErrServerBusy
+		}
+		if r.admitted.CompareAndSwap(admitted, admitted+1) {
+			break
+		}
+	}
+
+	r.jobs <- scheduledJob{run: job, reject: reject}
+	// The workers may have drained the queue just before the job arrived
+	if r.stopped.Load() {
+		r.drain()
+	}
+	return nil
+}
+
+func newServerBusyResponse(msg *messages.JsonRPCMessage) *messages.JsonRPCMessage {
+	errResponse := messages.NewJsonRPCMessage()
+	errResponse.ID = msg.ID
+	errResponse.SessionID = msg.SessionID
+	errResponse.Error = &messages.ErrorResponse{
+		Code:    messages.JsonRPCErrorServerBusy,
+		Message: "Server busy, too many requests in progress",
+	}
+	return errResponse
+}
+
+func newServerStoppedResponse(msg *messages.JsonRPCMessage) *messages.JsonRPCMessage {
+	errResponse := messages.NewJsonRPCMessage()
+	errResponse.ID = msg.ID
+	errResponse.SessionID = msg.SessionID
+	errResponse.Error = &messages.ErrorResponse{
+		Code:    messages.JsonRPCErrorInternalError,
+		Message: "Server stopped before handling the request",
+	}
+	return errResponse
+}
+
+func (r *requestScheduler) queued() int {
+	return len(r.jobs)
+}
+
+// ActiveRequests returns the number of requests currently being handled
+func (s *DefaultServer) ActiveRequests() int {
+	return int(s.scheduler.active.Load())
+}
+
+// QueuedRequests returns the number of requests waiting for a free worker
+func (s *DefaultServer) QueuedRequests() int {
+	return s.scheduler.queued()
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// ReflectSchema derives a JSON Schema from a Go type, struct fields are described by the json, description, required, enum, min, max and default tags
+func ReflectSchema(t reflect.Type) (map[string]interface{}, error) {
+	return reflectSchema(t, make(map[reflect.Type]bool))
+}
+
+func reflectSchema(t reflect.Type, visiting map[reflect.Type]bool) (map[string]interface{}, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	case reflect.Slice, reflect.Array:
+		// encoding/json writes byte slices as base64 strings
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := reflectSchema(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		values, err := reflectSchema(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		if visiting[t] {
+			return nil, fmt.Errorf("recursive type %s is not supported", t)
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		schema := map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		}
+		required := make([]string, 0)
+		if err := reflectStructFields(t, schema["properties"].(map[string]interface{}), &required, visiting); err != nil {
+			return nil, err
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// reflectStructFields follows encoding/json naming, embedded structs without a json name are flattened
+func reflectStructFields(t reflect.Type, properties map[string]interface{}, required *[]string, visiting map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			if err := reflectStructFields(fieldType, properties, required, visiting); err != nil {
+				return err
+			}
+			continue
+		}
+
+		property, err := reflectSchema(field.Type, visiting)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if err := applySchemaTags(property, field); err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+
+		properties[name] = property
+		if isRequired, _ := strconv.ParseBool(field.Tag.Get("required")); isRequired {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() && !field.Anonymous {
+		return "", true
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, false
+}
+
+func applySchemaTags(property map[string]interface{}, field reflect.StructField) error {
+	if description := field.Tag.Get("description"); description != "" {
+		property["description"] = description
+	}
+
+	if enum := field.Tag.Get("enum"); enum != "" {
+		values := make([]interface{}, 0)
+		for _, option := range strings.Split(enum, ",") {
+			value, err := parseTagValue(property, strings.TrimSpace(option))
+			if err != nil {
+				return fmt.Errorf("invalid enum value %q: %w", option, err)
+			}
+			values = append(values, value)
+		}
+		property["enum"] = values
+	}
+
+	if defaultValue := field.Tag.Get("default"); defaultValue != "" {
+		value, err := parseTagValue(property, defaultValue)
+		if err != nil {
+			return fmt.Errorf("invalid default %q: %w", defaultValue, err)
+		}
+		property["default"] = value
+	}
+
+	// min and max bound the value of numbers, the length of strings and the size of arrays
+	bounds := map[string][2]string{
+		"integer": {"minimum", "maximum"},
+		"number":  {"minimum", "maximum"},
+		"string":  {"minLength", "maxLength"},
+		"array":   {"minItems", "maxItems"},
+	}
+	keywords, bounded := bounds[fmt.Sprint(property["type"])]
+	for i, tag := range []string{"min", "max"} {
+		text := field.Tag.Get(tag)
+		if text == "" {
+			continue
+		}
+		if !bounded {
+			return fmt.Errorf("%s is not supported for %v", tag, property["type"])
+		}
+		bound, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", tag, text, err)
+		}
+		property[keywords[i]] = bound
+	}
+	return nil
+}
+
+// parseTagValue reads a tag value as the JSON type of the property
+func parseTagValue(property map[string]interface{}, text string) (interface{}, error) {
+	switch property["type"] {
+	case "string":
+		return text, nil
+	case "integer":
+		return strconv.ParseInt(text, 10, 64)
+	case "number":
+		return strconv.ParseFloat(text, 64)
+	case "boolean":
+		return strconv.ParseBool(text)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
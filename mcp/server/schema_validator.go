@@ -0,0 +1,515 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSchemaDepth stops $ref cycles that never consume any of the validated value
+const maxSchemaDepth = 64
+
+type SchemaViolation struct {
+	Path    string `json:"path"` // JSON Pointer into the validated value, empty for the value itself
+	Message string `json:"message"`
+}
+
+type SchemaValidationError struct {
+	Violations []SchemaViolation `json:"violations"`
+}
+
+func (e *SchemaValidationError) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		path := violation.Path
+		if path == "" {
+			path = "/"
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", path, violation.Message))
+	}
+	return "schema validation failed: " + strings.Join(parts, "; ")
+}
+
+// SchemaValidator checks values against a draft 2020-12 subset: type, enum, const, properties, patternProperties, required,
+// additionalProperties, propertyNames, dependentSchemas, items, prefixItems, contains, numeric and length ranges, pattern,
+// allOf, anyOf, oneOf, not, if/then/else and local $ref into $defs
+type SchemaValidator struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+func NewSchemaValidator(schema map[string]interface{}) (*SchemaValidator, error) {
+	var root interface{}
+	if err := remarshal(schema, &root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	validator := &SchemaValidator{
+		root:     root,
+		patterns: make(map[string]*regexp.Regexp),
+	}
+	if err := validator.compilePatterns(root); err != nil {
+		return nil, err
+	}
+	return validator, nil
+}
+
+// ValidateSchema is a one-off validation, prefer NewSchemaValidator for schemas used repeatedly
+func ValidateSchema(schema map[string]interface{}, value interface{}) error {
+	validator, err := NewSchemaValidator(schema)
+	if err != nil {
+		return err
+	}
+	return validator.Validate(value)
+}
+
+// Keywords whose value is a subschema, a list of subschemas or a map of subschemas, other keywords hold plain data
+var (
+	subschemaKeywords     = []string{"additionalProperties", "items", "not", "if", "then", "else", "contains", "propertyNames"}
+	subschemaListKeywords = []string{"prefixItems", "allOf", "anyOf", "oneOf"}
+	subschemaMapKeywords  = []string{"properties", "patternProperties", "$defs", "definitions", "dependentSchemas"}
+)
+
+// compilePatterns compiles the pattern keywords and patternProperties keys of a schema, data such as const, enum and default is skipped
+func (v *SchemaValidator) compilePatterns(node interface{}) error {
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		if err := v.compilePattern(pattern); err != nil {
+			return err
+		}
+	}
+	if patternProperties, ok := schema["patternProperties"].(map[string]interface{}); ok {
+		for pattern := range patternProperties {
+			if err := v.compilePattern(pattern); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, keyword := range subschemaKeywords {
+		if err := v.compilePatterns(schema[keyword]); err != nil {
+			return err
+		}
+	}
+	for _, keyword := range subschemaListKeywords {
+		children, _ := schema[keyword].([]interface{})
+		for _, child := range children {
+			if err := v.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range subschemaMapKeywords {
+		children, _ := schema[keyword].(map[string]interface{})
+		for _, child := range children {
+			if err := v.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pattern returns a precompiled pattern, patterns only reachable through unusual $ref targets are compiled on use
+func (v *SchemaValidator) pattern(pattern string) (*regexp.Regexp, error) {
+	if compiled, exist := v.patterns[pattern]; exist {
+		return compiled, nil
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema pattern %q: %w", pattern, err)
+	}
+	return compiled, nil
+}
+
+func (v *SchemaValidator) compilePattern(pattern string) error {
+	if _, exist := v.patterns[pattern]; exist {
+		return nil
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid schema pattern %q: %w", pattern, err)
+	}
+	v.patterns[pattern] = compiled
+	return nil
+}
+
+// Validate returns a *SchemaValidationError listing every violation, or nil
+func (v *SchemaValidator) Validate(value interface{}) error {
+	var normalized interface{}
+	if err := remarshal(value, &normalized); err != nil {
+		return fmt.Errorf("value is not JSON: %w", err)
+	}
+
+	violations := make([]SchemaViolation, 0)
+	v.validate(v.root, normalized, "", 0, &violations)
+	if len(violations) > 0 {
+		return &SchemaValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func (v *SchemaValidator) validate(schemaNode interface{}, value interface{}, path string, depth int, violations *[]SchemaViolation) {
+	report := func(format string, args ...interface{}) {
+		*violations = append(*violations, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if depth > maxSchemaDepth {
+		report("schema nesting is too deep")
+		return
+	}
+
+	switch schema := schemaNode.(type) {
+	case bool:
+		if !schema {
+			report("no value is allowed")
+		}
+		return
+	case map[string]interface{}:
+		if ref, ok := schema["$ref"].(string); ok {
+			target, err := v.resolveRef(ref)
+			if err != nil {
+				report("%v", err)
+				return
+			}
+			v.validate(target, value, path, depth+1, violations)
+		}
+		v.validateComposition(schema, value, path, depth, violations, report)
+
+		if !v.validateType(schema, value, report) {
+			// Keywords for other types would only repeat the type mismatch
+			return
+		}
+		v.validateValue(schema, value, report)
+
+		switch typed := value.(type) {
+		case map[string]interface{}:
+			v.validateObject(schema, typed, path, depth, violations, report)
+		case []interface{}:
+			v.validateArray(schema, typed, path, depth, violations, report)
+		}
+	}
+}
+
+// validateComposition applies allOf, anyOf, oneOf, not and if/then/else, which combine subschemas on the same value
+func (v *SchemaValidator) validateComposition(schema map[string]interface{}, value interface{}, path string, depth int, violations *[]SchemaViolation, report func(string, ...interface{})) {
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, child := range all {
+			v.validate(child, value, path, depth+1, violations)
+		}
+	}
+	if options, ok := schema["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, child := range options {
+			if v.matches(child, value, path, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			report("must match at least one schema of anyOf")
+		}
+	}
+	if options, ok := schema["oneOf"].([]interface{}); ok {
+		matched := 0
+		for _, child := range options {
+			if v.matches(child, value, path, depth+1) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			report("must match exactly one schema of oneOf, matched %d", matched)
+		}
+	}
+	if not, ok := schema["not"]; ok && v.matches(not, value, path, depth+1) {
+		report("must not match the schema of not")
+	}
+	if condition, ok := schema["if"]; ok {
+		branch := "else"
+		if v.matches(condition, value, path, depth+1) {
+			branch = "then"
+		}
+		if child, ok := schema[branch]; ok {
+			v.validate(child, value, path, depth+1, violations)
+		}
+	}
+}
+
+// matches reports whether value satisfies a subschema without recording its violations
+func (v *SchemaValidator) matches(schema interface{}, value interface{}, path string, depth int) bool {
+	var violations []SchemaViolation
+	v.validate(schema, value, path, depth, &violations)
+	return len(violations) == 0
+}
+
+// resolveRef follows a JSON Pointer within the root schema, remote references are not supported
+func (v *SchemaValidator) resolveRef(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+
+	node := v.root
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer == "" {
+		return node, nil
+	}
+
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch typed := node.(type) {
+		case map[string]interface{}:
+			child, exist := typed[token]
+			if !exist {
+				return nil, fmt.Errorf("unresolvable $ref %q", ref)
+			}
+			node = child
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(typed) {
+				return nil, fmt.Errorf("unresolvable $ref %q", ref)
+			}
+			node = typed[index]
+		default:
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	return node, nil
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if typed == math.Trunc(typed) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func (v *SchemaValidator) validateType(schema map[string]interface{}, value interface{}, report func(string, ...interface{})) bool {
+	var allowed []string
+	switch typed := schema["type"].(type) {
+	case nil:
+		return true
+	case string:
+		allowed = []string{typed}
+	case []interface{}:
+		for _, name := range typed {
+			if text, ok := name.(string); ok {
+				allowed = append(allowed, text)
+			}
+		}
+	}
+
+	actual := jsonTypeOf(value)
+	for _, name := range allowed {
+		if name == actual || (name == "number" && actual == "integer") {
+			return true
+		}
+	}
+	report("expected %s, got %s", strings.Join(allowed, " or "), actual)
+	return false
+}
+
+func schemaNumber(schema map[string]interface{}, key string) (float64, bool) {
+	number, ok := schema[key].(float64)
+	return number, ok
+}
+
+func (v *SchemaValidator) validateValue(schema map[string]interface{}, value interface{}, report func(string, ...interface{})) {
+	if options, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, option := range options {
+			if reflect.DeepEqual(option, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			report("must be one of %v", options)
+		}
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, value) {
+		report("must be %v", constant)
+	}
+
+	switch typed := value.(type) {
+	case float64:
+		if minimum, ok := schemaNumber(schema, "minimum"); ok && typed < minimum {
+			report("must be at least %v", minimum)
+		}
+		if maximum, ok := schemaNumber(schema, "maximum"); ok && typed > maximum {
+			report("must be at most %v", maximum)
+		}
+		if minimum, ok := schemaNumber(schema, "exclusiveMinimum"); ok && typed <= minimum {
+			report("must be greater than %v", minimum)
+		}
+		if maximum, ok := schemaNumber(schema, "exclusiveMaximum"); ok && typed >= maximum {
+			report("must be less than %v", maximum)
+		}
+		if divisor, ok := schemaNumber(schema, "multipleOf"); ok && divisor > 0 {
+			if !isMultipleOf(typed, divisor) {
+				report("must be a multiple of %v", divisor)
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(typed))
+		if minLength, ok := schemaNumber(schema, "minLength"); ok && length < minLength {
+			report("must be at least %v characters", minLength)
+		}
+		if maxLength, ok := schemaNumber(schema, "maxLength"); ok && length > maxLength {
+			report("must be at most %v characters", maxLength)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if compiled, err := v.pattern(pattern); err != nil {
+				report("%v", err)
+			} else if !compiled.MatchString(typed) {
+				report("must match pattern %q", pattern)
+			}
+		}
+	}
+}
+
+func (v *SchemaValidator) validateObject(schema map[string]interface{}, object map[string]interface{}, path string, depth int, violations *[]SchemaViolation, report func(string, ...interface{})) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if key, ok := name.(string); ok {
+				if _, exist := object[key]; !exist {
+					*violations = append(*violations, SchemaViolation{Path: path + "/" + escapePointer(key), Message: "is required"})
+				}
+			}
+		}
+	}
+
+	if minimum, ok := schemaNumber(schema, "minProperties"); ok && float64(len(object)) < minimum {
+		report("must have at least %v properties", minimum)
+	}
+	if maximum, ok := schemaNumber(schema, "maxProperties"); ok && float64(len(object)) > maximum {
+		report("must have at most %v properties", maximum)
+	}
+
+	if names, ok := schema["propertyNames"]; ok {
+		for _, key := range sortedKeys(object) {
+			if !v.matches(names, key, path, depth+1) {
+				report("property name %q is not allowed", key)
+			}
+		}
+	}
+	dependentSchemas, _ := schema["dependentSchemas"].(map[string]interface{})
+	for _, key := range sortedKeys(dependentSchemas) {
+		if _, exist := object[key]; exist {
+			v.validate(dependentSchemas[key], object, path, depth+1, violations)
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	patternProperties, _ := schema["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"]
+	for _, key := range sortedKeys(object) {
+		childPath := path + "/" + escapePointer(key)
+		property, matched := properties[key]
+		if matched {
+			v.validate(property, object[key], childPath, depth+1, violations)
+		}
+		for _, pattern := range sortedKeys(patternProperties) {
+			compiled, err := v.pattern(pattern)
+			if err != nil {
+				report("%v", err)
+				continue
+			}
+			if compiled.MatchString(key) {
+				matched = true
+				v.validate(patternProperties[pattern], object[key], childPath, depth+1, violations)
+			}
+		}
+		if !matched && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				*violations = append(*violations, SchemaViolation{Path: childPath, Message: "is not allowed"})
+			} else {
+				v.validate(additional, object[key], childPath, depth+1, violations)
+			}
+		}
+	}
+}
+
+func (v *SchemaValidator) validateArray(schema map[string]interface{}, array []interface{}, path string, depth int, violations *[]SchemaViolation, report func(string, ...interface{})) {
+	if minItems, ok := schemaNumber(schema, "minItems"); ok && float64(len(array)) < minItems {
+		report("must have at least %v items", minItems)
+	}
+	if maxItems, ok := schemaNumber(schema, "maxItems"); ok && float64(len(array)) > maxItems {
+		report("must have at most %v items", maxItems)
+	}
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+		for i := range array {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(array[i], array[j]) {
+					report("items %d and %d are equal", j, i)
+				}
+			}
+		}
+	}
+
+	if contains, ok := schema["contains"]; ok {
+		found := false
+		for i, item := range array {
+			if v.matches(contains, item, path+"/"+strconv.Itoa(i), depth+1) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			report("must contain an item matching the schema of contains")
+		}
+	}
+
+	prefixItems, _ := schema["prefixItems"].([]interface{})
+	items, hasItems := schema["items"]
+	for i, item := range array {
+		itemPath := path + "/" + strconv.Itoa(i)
+		if i < len(prefixItems) {
+			v.validate(prefixItems[i], item, itemPath, depth+1, violations)
+		} else if hasItems {
+			v.validate(items, item, itemPath, depth+1, violations)
+		}
+	}
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isMultipleOf tolerates the rounding of decimal divisors, 0.07 / 0.01 is 7.000000000000001 in binary floating point
+func isMultipleOf(value float64, divisor float64) bool {
+	const relativeTolerance = 1e-9
+	quotient := value / divisor
+	return math.Abs(quotient-math.Round(quotient)) <= relativeTolerance*math.Max(1, math.Abs(quotient))
+}
@@ -0,0 +1,196 @@
+package server_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+func TestSchemaValidator(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"$defs": map[string]interface{}{
+			"address": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"city": map[string]interface{}{"type": "string", "minLength": 2},
+					"zip":  map[string]interface{}{"type": "string", "pattern": "^[0-9]{5}$"},
+				},
+				"required":             []string{"city"},
+				"additionalProperties": false,
+			},
+		},
+		"properties": map[string]interface{}{
+			"name":    map[string]interface{}{"type": "string"},
+			"age":     map[string]interface{}{"type": "integer", "minimum": 0, "exclusiveMaximum": 150},
+			"role":    map[string]interface{}{"enum": []string{"admin", "user"}},
+			"score":   map[string]interface{}{"type": []string{"number", "null"}},
+			"address": map[string]interface{}{"$ref": "#/$defs/address"},
+			"tags": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"maxItems":    3,
+				"uniqueItems": true,
+			},
+			"point": map[string]interface{}{
+				"type":        "array",
+				"prefixItems": []interface{}{map[string]interface{}{"type": "number"}, map[string]interface{}{"type": "number"}},
+				"items":       false,
+			},
+		},
+		"required": []string{"name", "age"},
+	}
+
+	validator, err := server.NewSchemaValidator(schema)
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+
+	valid := map[string]interface{}{
+		"name":    "Ada",
+		"age":     36,
+		"role":    "admin",
+		"score":   nil,
+		"address": map[string]interface{}{"city": "London", "zip": "12345"},
+		"tags":    []string{"math", "computing"},
+		"point":   []interface{}{1.5, 2},
+		"extra":   true,
+	}
+	if err := validator.Validate(valid); err != nil {
+		t.Errorf("expected valid value, got %v", err)
+	}
+
+	invalid := map[string]interface{}{
+		"age":     36.5,
+		"role":    "guest",
+		"score":   "high",
+		"address": map[string]interface{}{"zip": "ABCDE", "country": "UK"},
+		"tags":    []interface{}{"a", "a", 3, "b"},
+		"point":   []interface{}{1, 2, 3},
+	}
+	err = validator.Validate(invalid)
+	var validationErr *server.SchemaValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+
+	expected := []server.SchemaViolation{
+		{Path: "/name", Message: "is required"},
+		{Path: "/address/city", Message: "is required"},
+		{Path: "/address/country", Message: "is not allowed"},
+		{Path: "/address/zip", Message: `must match pattern "^[0-9]{5}$"`},
+		{Path: "/age", Message: "expected integer, got number"},
+		{Path: "/point/2", Message: "no value is allowed"},
+		{Path: "/role", Message: "must be one of [admin user]"},
+		{Path: "/score", Message: "expected number or null, got string"},
+		{Path: "/tags", Message: "must have at most 3 items"},
+		{Path: "/tags", Message: "items 0 and 1 are equal"},
+		{Path: "/tags/2", Message: "expected string, got integer"},
+	}
+	if !reflect.DeepEqual(validationErr.Violations, expected) {
+		t.Errorf("unexpected violations:\n%+v\nexpected:\n%+v", validationErr.Violations, expected)
+	}
+
+	if _, err := server.NewSchemaValidator(map[string]interface{}{"pattern": "("}); err == nil {
+		t.Errorf("expected invalid pattern to be refused")
+	}
+	if err := server.ValidateSchema(map[string]interface{}{"$ref": "#/$defs/missing"}, 1); err == nil {
+		t.Errorf("expected unresolvable $ref to be reported")
+	}
+}
+
+func TestSchemaValidatorPatterns(t *testing.T) {
+	// Data keywords may hold strings that are not valid regular expressions
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"glob": map[string]interface{}{"type": "string", "default": "*.go", "enum": []string{"*.go", "[a-"}, "examples": []string{"(("}},
+			"mode": map[string]interface{}{"const": "+x"},
+		},
+		"patternProperties": map[string]interface{}{
+			"^x-": map[string]interface{}{"type": "string"},
+		},
+		"additionalProperties": false,
+	}
+	validator, err := server.NewSchemaValidator(schema)
+	if err != nil {
+		t.Fatalf("data keywords should not be compiled as patterns: %v", err)
+	}
+
+	if err := validator.Validate(map[string]interface{}{"glob": "*.go", "x-trace": "on"}); err != nil {
+		t.Errorf("expected pattern property to be allowed, got %v", err)
+	}
+
+	err = validator.Validate(map[string]interface{}{"x-trace": 1, "other": true})
+	var validationErr *server.SchemaValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected *SchemaValidationError, got %v", err)
+	}
+	expected := []server.SchemaViolation{
+		{Path: "/other", Message: "is not allowed"},
+		{Path: "/x-trace", Message: "expected string, got integer"},
+	}
+	if !reflect.DeepEqual(validationErr.Violations, expected) {
+		t.Errorf("expected %+v, got %+v", expected, validationErr.Violations)
+	}
+
+	if _, err := server.NewSchemaValidator(map[string]interface{}{"patternProperties": map[string]interface{}{"[a-": true}}); err == nil {
+		t.Errorf("expected invalid patternProperties key to be rejected")
+	}
+}
+
+func TestSchemaValidatorMultipleOf(t *testing.T) {
+	cases := []struct {
+		divisor float64
+		value   float64
+		valid   bool
+	}{
+		{0.01, 0.07, true},
+		{0.01, 19.99, true},
+		{0.1, 0.3, true},
+		{0.5, 2.5, true},
+		{3, 9, true},
+		{0.01, 0.075, false},
+		{0.1, 0.35, false},
+		{3, 10, false},
+	}
+	for _, c := range cases {
+		err := server.ValidateSchema(map[string]interface{}{"type": "number", "multipleOf": c.divisor}, c.value)
+		if valid := err == nil; valid != c.valid {
+			t.Errorf("%v multipleOf %v: expected valid=%v, got %v", c.value, c.divisor, c.valid, err)
+		}
+	}
+}
+
+func TestSchemaValidatorComposition(t *testing.T) {
+	number := map[string]interface{}{"type": "number"}
+	str := map[string]interface{}{"type": "string"}
+	positive := map[string]interface{}{"type": "number", "minimum": 0}
+	cases := []struct {
+		name   string
+		schema map[string]interface{}
+		value  interface{}
+		valid  bool
+	}{
+		{"anyOf match", map[string]interface{}{"anyOf": []interface{}{number, str}}, "a", true},
+		{"anyOf mismatch", map[string]interface{}{"anyOf": []interface{}{number, str}}, true, false},
+		{"oneOf match", map[string]interface{}{"oneOf": []interface{}{number, str}}, 1, true},
+		{"oneOf mismatch", map[string]interface{}{"oneOf": []interface{}{number, str}}, nil, false},
+		{"oneOf matching twice", map[string]interface{}{"oneOf": []interface{}{number, positive}}, 1, false},
+		{"allOf", map[string]interface{}{"allOf": []interface{}{number, positive}}, -1, false},
+		{"not", map[string]interface{}{"not": str}, "a", false},
+		{"if then", map[string]interface{}{"if": str, "then": map[string]interface{}{"minLength": 2}, "else": positive}, "a", false},
+		{"if else", map[string]interface{}{"if": str, "then": map[string]interface{}{"minLength": 2}, "else": positive}, 5, true},
+		{"contains", map[string]interface{}{"contains": str}, []interface{}{1, 2}, false},
+		{"propertyNames", map[string]interface{}{"propertyNames": map[string]interface{}{"pattern": "^[a-z]+$"}}, map[string]interface{}{"Name": 1}, false},
+		{"dependentSchemas", map[string]interface{}{"dependentSchemas": map[string]interface{}{"card": map[string]interface{}{"required": []string{"cvc"}}}}, map[string]interface{}{"card": "1"}, false},
+	}
+	for _, c := range cases {
+		err := server.ValidateSchema(c.schema, c.value)
+		if valid := err == nil; valid != c.valid {
+			t.Errorf("%s: expected valid=%v, got %v", c.name, c.valid, err)
+		}
+	}
+}
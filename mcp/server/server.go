@@ -2,10 +2,12 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/alwint3r/mcp2go/mcp/messages"
@@ -14,18 +16,24 @@ import (
 const (
 	ProtocolVersion20241105 = "2024-11-05"
 	ProtocolVersion20250326 = "2025-03-26"
+	ProtocolVersion20250618 = "2025-06-18"
 )
 
-type CapabilityProperties struct {
-	ListChanged *bool `json:"listChanged,omitempty"`
-	Subscribe   *bool `json:"subscribe,omitempty"`
+// IsKnownProtocolVersion reports whether version is one of the protocol revisions this library implements
+func IsKnownProtocolVersion(version string) bool {
+	switch version {
+	case ProtocolVersion20241105, ProtocolVersion20250326, ProtocolVersion20250618:
+		return true
+	}
+	return false
 }
 
 type Capabilities struct {
-	Logging   *CapabilityProperties `json:"logging,omitempty"`
-	Tools     *CapabilityProperties `json:"tools,omitempty"`
-	Prompts   *CapabilityProperties `json:"prompts,omitempty"`
-	Resources *CapabilityProperties `json:"resources,omitempty"`
+	Logging     *messages.CapabilityProperties `json:"logging,omitempty"`
+	Tools       *messages.CapabilityProperties `json:"tools,omitempty"`
+	Prompts     *messages.CapabilityProperties `json:"prompts,omitempty"`
+	Resources   *messages.CapabilityProperties `json:"resources,omitempty"`
+	Completions *messages.CapabilityProperties `json:"completions,omitempty"`
 }
 
 type RequestError struct {
@@ -46,24 +54,120 @@ type DefaultServer struct {
 	Name                string
 	Version             string
 	ProtocolVersion     string
+	supportedVersions   []string
 	capabilities        Capabilities
 	transport           Transport
 	requestHandlers     RequestHandlersMap
 	cancellableRequests CancellableRequestMap
 	cancelMutex         sync.RWMutex // Protects access to cancellableRequests
 	toolManager         *ToolManager
+	resourceManager     *ResourceManager
+	promptManager       *PromptManager
 	logger              *Logger
 	config              ServerConfig
 	closeSignalChan     chan int
 	closeOnce           sync.Once
+	sessions            map[string]*session
+	sessionsMutex       sync.RWMutex
+	pendingRequests     PendingRequestMap
+	pendingMutex        sync.Mutex
+	nextRequestID       atomic.Int64
+	initializedHooks    []func(ClientInfo)
+	rootsChangedHooks   []func(SessionInfo, []Root)
+	pingTimeoutHooks    []func(SessionInfo)
+	scheduler           *requestScheduler
+	hooksMutex          sync.RWMutex
+}
+
+// requestKey identifies an in-flight request, IDs are only unique within a session
+type requestKey struct {
+	sessionID string
+	id        interface{}
 }
 
 type ctxRequestIdKey struct{}
+type ctxServerKey struct{}
+
+type initializeParams struct {
+	ProtocolVersion string                      `json:"protocolVersion"`
+	Capabilities    messages.ClientCapabilities `json:"capabilities"`
+	ClientInfo      ClientInfo                  `json:"clientInfo"`
+}
+
+// remarshal converts between loosely typed JSON maps and typed structs
+func remarshal(source interface{}, target interface{}) error {
+	marshaled, err := json.Marshal(source)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %T: %w", source, err)
+	}
+
+	return json.Unmarshal(marshaled, target)
+}
+
+func decodeParams(params *messages.JsonRPCParams, target interface{}) error {
+	if params == nil {
+		return errors.New("missing params")
+	}
+
+	return remarshal(params, target)
+}
+
+func serverFromContext(ctx context.Context) *DefaultServer {
+	s, _ := ctx.Value(ctxServerKey{}).(*DefaultServer)
+	return s
+}
+
+// negotiateProtocolVersion picks the client's version when supported, the latest supported version otherwise
+func (s *DefaultServer) negotiateProtocolVersion(requested string) string {
+	latest := s.ProtocolVersion
+	for _, version := range s.supportedVersions {
+		if version == requested {
+			return version
+		}
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest
+}
 
 func (s *DefaultServer) handleInitializeRequest(ctx context.Context, request messages.Request) (*messages.JsonRPCResult, *RequestError) {
+	var params initializeParams
+	if err := decodeParams(request.Params, &params); err != nil || params.ProtocolVersion == "" {
+		return nil, &RequestError{
+			Err: err,
+			ForResponse: messages.ErrorResponse{
+				Code:    messages.JsonRPCErrorInvalidParams,
+				Message: "invalid initialize params",
+			},
+		}
+	}
+
+	protocolVersion := s.negotiateProtocolVersion(params.ProtocolVersion)
+	if protocolVersion != params.ProtocolVersion {
+		s.logger.Info("Client requested unsupported protocol version %s, offering %s", params.ProtocolVersion, protocolVersion)
+	}
+
+	err := sessionFromContext(ctx).beginInitialize(SessionInfo{
+		ProtocolVersion:    protocolVersion,
+		ClientInfo:         params.ClientInfo,
+		ClientCapabilities: params.Capabilities,
+	})
+	if err != nil {
+		s.logger.Warn("Rejecting initialize for session %q: %v", request.SessionID, err)
+		return nil, &RequestError{
+			Err: err,
+			ForResponse: messages.ErrorResponse{
+				Code:    messages.JsonRPCErrorInvalidRequest,
+				Message: "Session already initialized",
+			},
+		}
+	}
+	s.logger.Info("Initializing session %q for %s %s with protocol %s", request.SessionID, params.ClientInfo.Name, params.ClientInfo.Version, protocolVersion)
+
 	result := messages.JsonRPCResult{
 		"capabilities":    s.capabilities,
-		"protocolVersion": s.ProtocolVersion,
+		"protocolVersion": protocolVersion,
 		"serverInfo": map[string]string{
 			"name":    s.Name,
 			"version": s.Version,
@@ -73,10 +177,54 @@ func (s *DefaultServer) handleInitializeRequest(ctx context.Context, request mes
 	return &result, nil
 }
 
+// checkSessionState rejects requests the session is not allowed to make in its current lifecycle state
+func checkSessionState(current *session, method string) *messages.ErrorResponse {
+	switch current.state() {
+	case SessionUninitialized:
+		if method == "initialize" || method == "ping" {
+			return nil
+		}
+		return &messages.ErrorResponse{
+			Code:    messages.JsonRPCErrorInvalidRequest,
+			Message: "Session not initialized",
+		}
+	case SessionInitializing:
+		// Until notifications/initialized arrives the client may only ping
+		if method == "ping" {
+			return nil
+		}
+		return &messages.ErrorResponse{
+			Code:    messages.JsonRPCErrorInvalidRequest,
+			Message: "Session initialization not complete",
+		}
+	case SessionShuttingDown:
+		return &messages.ErrorResponse{
+			Code:    messages.JsonRPCErrorInvalidRequest,
+			Message: "Session is shutting down",
+		}
+	}
+	return nil
+}
+
 func (s *DefaultServer) handleRequest(ctx context.Context, request messages.Request) *messages.JsonRPCMessage {
+	current := s.sessionFor(request.SessionID)
 	ctxWithValue := context.WithValue(ctx, ctxRequestIdKey{}, request.ID)
+	ctxWithValue = context.WithValue(ctxWithValue, ctxSessionKey{}, current)
+	ctxWithValue = context.WithValue(ctxWithValue, ctxServerKey{}, s)
+	var progress *ProgressReporter
+	if token, ok := messages.ProgressToken(request.Params); ok {
+		progress = s.newProgressReporter(request.SessionID, token)
+		ctxWithValue = context.WithValue(ctxWithValue, ctxProgressKey{}, progress)
+	}
 	message := messages.NewJsonRPCMessage()
 	message.ID = request.ID
+	message.SessionID = request.SessionID
+
+	if stateErr := checkSessionState(current, request.Method); stateErr != nil {
+		s.logger.Warn("Rejecting %s from session %q: %s", request.Method, request.SessionID, current.state())
+		message.Error = stateErr
+		return message
+	}
 
 	handler, err := s.findRequestHandler(&request)
 	if err != nil {
@@ -97,16 +245,20 @@ func (s *DefaultServer) handleRequest(ctx context.Context, request messages.Requ
 	cancellableContext, cancel := context.WithCancel(ctxWithValue)
 	defer cancel()
 
+	key := requestKey{sessionID: request.SessionID, id: request.ID}
 	s.cancelMutex.Lock()
-	s.cancellableRequests[request.ID] = cancel
+	s.cancellableRequests[key] = cancel
 	s.cancelMutex.Unlock()
 
 	handlerResult, requestErr := handler(cancellableContext, request)
 
 	s.cancelMutex.Lock()
-	delete(s.cancellableRequests, request.ID)
+	delete(s.cancellableRequests, key)
 	s.cancelMutex.Unlock()
 
+	// Progress held back by rate limiting has to reach the client before the response
+	progress.flush(ctxWithValue)
+
 	if requestErr != nil {
 		message.Error = &requestErr.ForResponse
 		return message
@@ -116,16 +268,53 @@ func (s *DefaultServer) handleRequest(ctx context.Context, request messages.Requ
 	return message
 }
 
-func (s *DefaultServer) handleNotification(message *messages.JsonRPCMessage) {
-	if *message.Method == "notifications/cancelled" {
+func (s *DefaultServer) handleInitializedNotification(message *messages.JsonRPCMessage) {
+	current := s.sessionFor(message.SessionID)
+	if !current.markReady() {
+		s.logger.Warn("Ignoring initialized notification for session %q in state %s", message.SessionID, current.state())
+		return
+	}
+
+	s.logger.Info("Session %q is ready", message.SessionID)
+	clientInfo := current.sessionInfo().ClientInfo
+
+	s.hooksMutex.RLock()
+	hooks := append([]func(ClientInfo){}, s.initializedHooks...)
+	s.hooksMutex.RUnlock()
+
+	go func() {
+		for _, hook := range hooks {
+			hook(clientInfo)
+		}
+	}()
+}
+
+// OnInitialized registers a hook that runs once a client has sent notifications/initialized
+func (s *DefaultServer) OnInitialized(hook func(ClientInfo)) {
+	s.hooksMutex.Lock()
+	defer s.hooksMutex.Unlock()
+
+	s.initializedHooks = append(s.initializedHooks, hook)
+}
+
+func (s *DefaultServer) handleNotification(ctx context.Context, message *messages.JsonRPCMessage) {
+	if *message.Method == "notifications/initialized" {
+		s.handleInitializedNotification(message)
+	} else if *message.Method == "notifications/roots/list_changed" {
+		s.handleRootsListChangedNotification(ctx, message.SessionID)
+	} else if *message.Method == "notifications/cancelled" {
 		if message.Params == nil {
 			s.logger.Warn("Received cancellations notification with empty params")
 			return
 		}
 		params := *message.Params
 		if requestID, ok := params["requestId"]; ok {
+			if !messages.IsValidID(requestID) {
+				s.logger.Warn("Ignoring cancellation with invalid request ID type %T", requestID)
+				return
+			}
 			s.logger.Info("Cancellation request received for ID: %v", requestID)
-			if cancelled := s.cancelRequest(requestID); cancelled {
+			if cancelled := s.cancelRequest(message.SessionID, requestID); cancelled {
 				s.logger.Info("Successfully cancelled request ID: %v", requestID)
 			} else {
 				s.logger.Warn("Could not cancel request ID: %v (not found)", requestID)
@@ -154,7 +343,10 @@ func (s *DefaultServer) handleToolListRequest(ctx context.Context, request messa
 }
 
 func (s *DefaultServer) handleToolCallRequest(ctx context.Context, request messages.Request) (*messages.JsonRPCResult, *RequestError) {
-	params := *request.Params
+	var params messages.JsonRPCParams
+	if request.Params != nil {
+		params = *request.Params
+	}
 	toolName, ok := params["name"].(string)
 	if !ok {
 		return nil, &RequestError{
@@ -165,7 +357,11 @@ func (s *DefaultServer) handleToolCallRequest(ctx context.Context, request messa
 			},
 		}
 	}
-	arguments, ok := params["arguments"].(map[string]interface{})
+	// arguments is optional, an absent one is validated as an empty object so required properties get reported
+	arguments := map[string]interface{}{}
+	if rawArguments, exist := params["arguments"]; exist && rawArguments != nil {
+		arguments, ok = rawArguments.(map[string]interface{})
+	}
 	if !ok {
 		return nil, &RequestError{
 			Err: nil,
@@ -176,22 +372,235 @@ func (s *DefaultServer) handleToolCallRequest(ctx context.Context, request messa
 		}
 	}
 
-	toolCallResult := s.toolManager.CallTool(ctx, toolName, arguments)
+	// One snapshot serves validation, the call and the output check, so a concurrent ReplaceTool can't mix versions
+	entry, err := s.toolManager.findTool(toolName)
+	if err != nil {
+		return toolResultResponse(newToolErrorResult(err)), nil
+	}
+	return toolResultResponse(entry.validateAndCall(ctx, arguments)), nil
+}
+
+func toolResultResponse(toolCallResult ToolResult) *messages.JsonRPCResult {
 	response := messages.JsonRPCResult{
 		"content": toolCallResult.Content,
 		"isError": toolCallResult.IsError,
 	}
+	if toolCallResult.StructuredContent != nil {
+		response["structuredContent"] = toolCallResult.StructuredContent
+	}
+	return &response
+}
+
+func (s *DefaultServer) handleResourceListRequest(ctx context.Context, request messages.Request) (*messages.JsonRPCResult, *RequestError) {
+	resources := s.resourceManager.ListAllResources()
+	response := &messages.JsonRPCResult{
+		"resources": resources,
+	}
+
+	return response, nil
+}
+
+func (s *DefaultServer) handleResourceTemplateListRequest(ctx context.Context, request messages.Request) (*messages.JsonRPCResult, *RequestError) {
+	resourceTemplates := s.resourceManager.ListAllResourceTemplates()
+	response := &messages.JsonRPCResult{
+		"resourceTemplates": resourceTemplates,
+	}
+
+	return response, nil
+}
+
+func resourceURIFromParams(request messages.Request) (string, *RequestError) {
+	var uri string
+	if request.Params != nil {
+		uri, _ = (*request.Params)["uri"].(string)
+	}
+	if uri == "" {
+		return "", &RequestError{
+			Err: nil,
+			ForResponse: messages.ErrorResponse{
+				Code:    messages.JsonRPCErrorInvalidParams,
+				Message: "invalid resource uri",
+			},
+		}
+	}
+	return uri, nil
+}
+
+func (s *DefaultServer) handleResourceReadRequest(ctx context.Context, request messages.Request) (*messages.JsonRPCResult, *RequestError) {
+	uri, requestErr := resourceURIFromParams(request)
+	if requestErr != nil {
+		return nil, requestErr
+	}
+
+	contents, err := s.resourceManager.ReadResource(ctx, uri)
+	if errors.Is(err, ErrResourceNotFound) {
+		var data interface{} = map[string]string{"uri": uri}
+		return nil, &RequestError{
+			Err: err,
+			ForResponse: messages.ErrorResponse{
+				Code:    messages.JsonRPCErrorResourceNotFound,
+				Message: "Resource not found",
+				Data:    &data,
+			},
+		}
+	} else if err != nil {
+		s.logger.Error("Failed to read resource %s: %v", uri, err)
+		return nil, &RequestError{
+			Err: err,
+			ForResponse: messages.ErrorResponse{
+				Code:    messages.JsonRPCErrorInternalError,
+				Message: fmt.Sprintf("failed to read resource: %v", err),
+			},
+		}
+	}
+
+	if contents == nil {
+		contents = make([]ResourceContents, 0)
+	}
+
+	response := messages.JsonRPCResult{
+		"contents": contents,
+	}
 	return &response, nil
 }
 
-func (s *DefaultServer) cancelRequest(id interface{}) bool {
+func (s *DefaultServer) handleResourceSubscribeRequest(ctx context.Context, request messages.Request) (*messages.JsonRPCResult, *RequestError) {
+	uri, requestErr := resourceURIFromParams(request)
+	if requestErr != nil {
+		return nil, requestErr
+	}
+
+	sessionFromContext(ctx).subscribe(uri)
+	s.logger.Debug("Session %q subscribed to %s", request.SessionID, uri)
+	return &messages.JsonRPCResult{}, nil
+}
+
+func (s *DefaultServer) handleResourceUnsubscribeRequest(ctx context.Context, request messages.Request) (*messages.JsonRPCResult, *RequestError) {
+	uri, requestErr := resourceURIFromParams(request)
+	if requestErr != nil {
+		return nil, requestErr
+	}
+
+	sessionFromContext(ctx).unsubscribe(uri)
+	s.logger.Debug("Session %q unsubscribed from %s", request.SessionID, uri)
+	return &messages.JsonRPCResult{}, nil
+}
+
+// NotifyResourceUpdated tells every session subscribed to uri that the resource has changed
+func (s *DefaultServer) NotifyResourceUpdated(uri string) error {
+	var errs []error
+	for _, current := range s.allSessions() {
+		if !current.isSubscribed(uri) {
+			continue
+		}
+
+		notification := messages.NewResourceUpdatedNotification(uri)
+		if err := s.sendNotification(context.Background(), current.id, notification); err != nil {
+			s.logger.Error("Failed to notify session %q about %s: %v", current.id, uri, err)
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// notifyToolListChanged tells every ready session to list the tools again, when the tools capability advertises listChanged
+func (s *DefaultServer) notifyToolListChanged() {
+	if s.capabilities.Tools == nil || s.capabilities.Tools.ListChanged == nil || !*s.capabilities.Tools.ListChanged {
+		return
+	}
+
+	for _, current := range s.allSessions() {
+		if current.state() != SessionReady {
+			continue
+		}
+
+		notification := messages.NewToolListChangedNotification()
+		if err := s.sendNotification(context.Background(), current.id, notification); err != nil {
+			s.logger.Error("Failed to notify session %q about tool list change: %v", current.id, err)
+		}
+	}
+}
+
+func (s *DefaultServer) handlePromptListRequest(ctx context.Context, request messages.Request) (*messages.JsonRPCResult, *RequestError) {
+	prompts := s.promptManager.ListAllPrompts()
+	response := &messages.JsonRPCResult{
+		"prompts": prompts,
+	}
+
+	return response, nil
+}
+
+func (s *DefaultServer) handlePromptGetRequest(ctx context.Context, request messages.Request) (*messages.JsonRPCResult, *RequestError) {
+	invalidParams := func(message string, err error) *RequestError {
+		return &RequestError{
+			Err: err,
+			ForResponse: messages.ErrorResponse{
+				Code:    messages.JsonRPCErrorInvalidParams,
+				Message: message,
+			},
+		}
+	}
+
+	if request.Params == nil {
+		return nil, invalidParams("invalid prompt name", nil)
+	}
+	params := *request.Params
+	promptName, ok := params["name"].(string)
+	if !ok {
+		return nil, invalidParams("invalid prompt name", nil)
+	}
+
+	arguments := make(map[string]string)
+	if rawArguments, exist := params["arguments"]; exist && rawArguments != nil {
+		argumentsMap, ok := rawArguments.(map[string]interface{})
+		if !ok {
+			return nil, invalidParams("invalid prompt arguments", nil)
+		}
+		for name, value := range argumentsMap {
+			stringValue, ok := value.(string)
+			if !ok {
+				return nil, invalidParams(fmt.Sprintf("prompt argument %s must be a string", name), nil)
+			}
+			arguments[name] = stringValue
+		}
+	}
+
+	result, err := s.promptManager.GetPrompt(ctx, promptName, arguments)
+	if errors.Is(err, ErrPromptNotFound) {
+		return nil, invalidParams(fmt.Sprintf("invalid prompt name: %s", promptName), err)
+	} else if errors.Is(err, ErrMissingPromptArguments) {
+		return nil, invalidParams(err.Error(), err)
+	} else if err != nil {
+		s.logger.Error("Failed to get prompt %s: %v", promptName, err)
+		return nil, &RequestError{
+			Err: err,
+			ForResponse: messages.ErrorResponse{
+				Code:    messages.JsonRPCErrorInternalError,
+				Message: fmt.Sprintf("failed to get prompt: %v", err),
+			},
+		}
+	}
+
+	response := messages.JsonRPCResult{
+		"messages": result.Messages,
+	}
+	if result.Description != "" {
+		response["description"] = result.Description
+	}
+	return &response, nil
+}
+
+// cancelRequest cancels a request of the given session, other sessions may reuse the same ID
+func (s *DefaultServer) cancelRequest(sessionID string, id interface{}) bool {
 	s.cancelMutex.Lock()
 	defer s.cancelMutex.Unlock()
 
-	cancel, exists := s.cancellableRequests[id]
+	key := requestKey{sessionID: sessionID, id: id}
+	cancel, exists := s.cancellableRequests[key]
 	if exists {
 		cancel()
-		delete(s.cancellableRequests, id)
+		delete(s.cancellableRequests, key)
 		return true
 	}
 	return false
@@ -210,61 +619,71 @@ func (s *DefaultServer) cancelAllRequest() {
 func (s *DefaultServer) Close() error {
 	s.closeOnce.Do(func() {
 		close(s.closeSignalChan)
+		for _, current := range s.allSessions() {
+			current.markShuttingDown()
+		}
 		s.cancelAllRequest()
 	})
 	return nil
 }
 
+func (s *DefaultServer) writeResponse(ctx context.Context, response *messages.JsonRPCMessage) {
+	withTimeoutCtx, cancel := context.WithTimeout(ctx, s.config.OutgoingMessageTimeoutSeconds*time.Second)
+	defer cancel()
+
+	if err := s.transport.Write(*response, withTimeoutCtx); err != nil {
+		s.logger.Error("Failed to write response: %v", err)
+		if errors.Is(err, ErrSessionNotFound) {
+			s.removeSession(response.SessionID)
+		}
+	}
+}
+
 func (s *DefaultServer) handleMessageFromTransport(ctx context.Context, msg *messages.JsonRPCMessage) {
 	if msg.JsonRPC != "2.0" {
 		s.logger.Warn("Received message with invalid JSON-RPC version: %s", msg.JsonRPC)
 		if msg.ID != nil {
-			errResponse := messages.NewJsonRPCMessage()
-			errResponse.ID = msg.ID
-			errResponse.Error = &messages.ErrorResponse{
-				Code:    messages.JsonRPCErrorInvalidRequest,
-				Message: "Invalid JSON-RPC protocol version",
-			}
-			withTimeoutCtx, cancel := context.WithTimeout(ctx, s.config.OutgoingMessageTimeoutSeconds*time.Second)
-			defer cancel()
-			if err := s.transport.Write(*errResponse, withTimeoutCtx); err != nil {
-				s.logger.Error("Failed to write error response: %v", err)
-			}
+			s.writeResponse(ctx, newInvalidRequestResponse(msg, "Invalid JSON-RPC protocol version"))
 		}
 		return
 	}
+	if !messages.IsValidID(msg.ID) {
+		s.logger.Warn("Received message with invalid ID type %T", msg.ID)
+		// Responses with such IDs are dropped, they can't belong to a request of ours
+		if msg.Method != nil {
+			s.writeResponse(ctx, newInvalidIDResponse(msg))
+		}
+		return
+	}
+
+	s.sessionFor(msg.SessionID)
 
 	if msg.IsRequest() {
 		request := messages.NewRequestFromJsonRPCMessage(*msg)
 		s.logger.Debug("Handling request: %s (ID: %v)", request.Method, request.ID)
-		go func(request messages.Request, ctx context.Context) {
-			withTimeoutCtx, cancel := context.WithTimeout(ctx, s.config.OutgoingMessageTimeoutSeconds*time.Second)
-			defer cancel()
+		err := s.scheduler.submit(func() {
 			response := s.handleRequest(ctx, request)
-			if err := s.transport.Write(*response, withTimeoutCtx); err != nil {
-				s.logger.Error("Failed to write response: %v", err)
-			}
-		}(request, ctx)
+			s.writeResponse(ctx, response)
+		}, func() {
+			// The server's context may be done already, the client still has to hear about its request
+			go s.writeResponse(context.WithoutCancel(ctx), newServerStoppedResponse(msg))
+		})
+		if err != nil {
+			s.logger.Warn("Rejecting request %s (ID: %v): %v", request.Method, request.ID, err)
+			go s.writeResponse(ctx, newServerBusyResponse(msg))
+		}
 
 	} else if msg.IsNotification() {
 		s.logger.Debug("Received notification: %s", *msg.Method)
-		go s.handleNotification(msg)
+		// Handled inline so that requests read after notifications/initialized see the ready session
+		s.handleNotification(ctx, msg)
 	} else if msg.IsResponse() {
 		s.logger.Debug("Received response message with ID: %v", msg.ID)
+		s.handleResponse(msg)
 	} else {
 		s.logger.Warn("Received invalid message type")
 		if msg.ID != nil {
-			errResponse := messages.NewJsonRPCMessage()
-			errResponse.ID = msg.ID
-			errResponse.Error = &messages.ErrorResponse{
-				Code:    messages.JsonRPCErrorInvalidRequest,
-				Message: "Invalid message type",
-			}
-			withTimeoutCtx, cancel := context.WithTimeout(ctx, s.config.OutgoingMessageTimeoutSeconds*time.Second)
-			defer cancel()
-			if err := s.transport.Write(*errResponse, withTimeoutCtx); err != nil {
-				s.logger.Error("Failed to write error response: %v", err)
-			}
+			s.writeResponse(ctx, newInvalidRequestResponse(msg, "Invalid message type"))
 		}
 	}
 
@@ -274,6 +693,17 @@ func (s *DefaultServer) Start(ctx context.Context) error {
 	s.logger.Info("Server started")
 	defer s.logger.Info("Server stopping")
 
+	s.scheduler.start(ctx, s.closeSignalChan)
+
+	if s.config.PingIntervalSeconds > 0 {
+		go s.pingLoop(ctx)
+	}
+
+	var batchChannel <-chan messages.JsonRPCBatch
+	if batchTransport, ok := s.transport.(BatchTransport); ok {
+		batchChannel = batchTransport.ReadBatch()
+	}
+
 	for {
 		select {
 		case <-s.closeSignalChan:
@@ -289,6 +719,13 @@ func (s *DefaultServer) Start(ctx context.Context) error {
 			}
 
 			s.handleMessageFromTransport(ctx, &msg)
+		case batch, ok := <-batchChannel:
+			if !ok {
+				batchChannel = nil
+				continue
+			}
+
+			go s.handleBatchFromTransport(ctx, batch)
 		}
 	}
 }
@@ -298,6 +735,13 @@ func NewDefaultServer(transport Transport, protocolVersion string, version strin
 }
 
 func NewDefaultServerWithConfig(transport Transport, protocolVersion string, version string, name string, config ServerConfig) *DefaultServer {
+	if config.PingTimeoutSeconds <= 0 {
+		config.PingTimeoutSeconds = NewDefaultConfig().PingTimeoutSeconds
+	}
+	if config.RequestTimeoutSeconds <= 0 {
+		config.RequestTimeoutSeconds = NewDefaultConfig().RequestTimeoutSeconds
+	}
+
 	logger := NewLogger(name)
 	logger.MinLevel = config.LogLevel
 	logger.ShowTime = config.ShowTimestamps
@@ -320,26 +764,54 @@ func NewDefaultServerWithConfig(transport Transport, protocolVersion string, ver
 		Name:                name,
 		transport:           transport,
 		ProtocolVersion:     protocolVersion,
+		supportedVersions:   []string{protocolVersion},
 		requestHandlers:     make(RequestHandlersMap),
 		cancellableRequests: make(CancellableRequestMap),
 		capabilities:        Capabilities{},
 		logger:              logger,
 		config:              config,
 		closeSignalChan:     make(chan int, 1),
+		sessions:            make(map[string]*session),
+		pendingRequests:     make(PendingRequestMap),
+		scheduler:           newRequestScheduler(config),
 	}
 
 	server.requestHandlers["initialize"] = server.handleInitializeRequest
+	server.requestHandlers["ping"] = server.handlePingRequest
+
+	if notifier, ok := transport.(SessionNotifier); ok {
+		notifier.OnSessionClosed(server.removeSession)
+	}
+
+	return server
+}
+
+// WithSupportedProtocolVersions sets the versions the server accepts during initialize, ProtocolVersion is always accepted
+func WithSupportedProtocolVersions(server *DefaultServer, versions ...string) *DefaultServer {
+	server.supportedVersions = []string{server.ProtocolVersion}
+	for _, version := range versions {
+		if version != server.ProtocolVersion {
+			server.supportedVersions = append(server.supportedVersions, version)
+		}
+	}
 
 	return server
 }
 
 func WithLoggingCapability(server *DefaultServer) *DefaultServer {
-	server.capabilities.Logging = &CapabilityProperties{}
+	server.capabilities.Logging = &messages.CapabilityProperties{}
+	server.requestHandlers["logging/setLevel"] = server.handleLoggingSetLevelRequest
+	return server
+}
+
+func WithCompletionsCapability(server *DefaultServer) *DefaultServer {
+	server.capabilities.Completions = &messages.CapabilityProperties{}
+	server.requestHandlers["completion/complete"] = server.handleCompletionRequest
 	return server
 }
 
 func WithToolsCapability(server *DefaultServer, listChanged, subscribe bool) *DefaultServer {
-	server.capabilities.Tools = &CapabilityProperties{}
+	server.capabilities.Tools = &messages.CapabilityProperties{}
 	if listChanged {
 		server.capabilities.Tools.ListChanged = &listChanged
 	}
@@ -352,7 +824,7 @@ func WithToolsCapability(server *DefaultServer, listChanged, subscribe bool) *De
 }
 
 func WithPromptsCapability(server *DefaultServer, listChanged, subscribe bool) *DefaultServer {
-	server.capabilities.Prompts = &CapabilityProperties{}
+	server.capabilities.Prompts = &messages.CapabilityProperties{}
 	if listChanged {
 		server.capabilities.Prompts.ListChanged = &listChanged
 	}
@@ -365,13 +837,15 @@ func WithPromptsCapability(server *DefaultServer, listChanged, subscribe bool) *
 }
 
 func WithResourcesCapability(server *DefaultServer, listChanged, subscribe bool) *DefaultServer {
-	server.capabilities.Resources = &CapabilityProperties{}
+	server.capabilities.Resources = &messages.CapabilityProperties{}
 	if listChanged {
 		server.capabilities.Resources.ListChanged = &listChanged
 	}
 
 	if subscribe {
 		server.capabilities.Resources.Subscribe = &subscribe
+		server.requestHandlers["resources/subscribe"] = server.handleResourceSubscribeRequest
+		server.requestHandlers["resources/unsubscribe"] = server.handleResourceUnsubscribeRequest
 	}
 
 	return server
@@ -379,8 +853,26 @@ func WithResourcesCapability(server *DefaultServer, listChanged, subscribe bool)
 
 func WithToolManager(server *DefaultServer, toolManager *ToolManager) *DefaultServer {
 	server.toolManager = toolManager
+	toolManager.OnListChanged(server.notifyToolListChanged)
 	server.requestHandlers["tools/list"] = server.handleToolListRequest
 	server.requestHandlers["tools/call"] = server.handleToolCallRequest
 
 	return server
 }
+
+func WithResourceManager(server *DefaultServer, resourceManager *ResourceManager) *DefaultServer {
+	server.resourceManager = resourceManager
+	server.requestHandlers["resources/list"] = server.handleResourceListRequest
+	server.requestHandlers["resources/read"] = server.handleResourceReadRequest
+	server.requestHandlers["resources/templates/list"] = server.handleResourceTemplateListRequest
+
+	return server
+}
+
+func WithPromptManager(server *DefaultServer, promptManager *PromptManager) *DefaultServer {
+	server.promptManager = promptManager
+	server.requestHandlers["prompts/list"] = server.handlePromptListRequest
+	server.requestHandlers["prompts/get"] = server.handlePromptGetRequest
+
+	return server
+}
@@ -0,0 +1,1049 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+// memoryTransport hands messages between a test and the server through channels
+type memoryTransport struct {
+	in  chan messages.JsonRPCMessage
+	out chan messages.JsonRPCMessage
+}
+
+func newMemoryTransport() *memoryTransport {
+	return &memoryTransport{
+		in:  make(chan messages.JsonRPCMessage, 10),
+		out: make(chan messages.JsonRPCMessage, 10),
+	}
+}
+
+func (m *memoryTransport) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (m *memoryTransport) Read() <-chan messages.JsonRPCMessage {
+	return m.in
+}
+
+// Write round-trips through JSON so tests see what a client would, and like a real transport gives up on a done context
+func (m *memoryTransport) Write(msg messages.JsonRPCMessage, ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	var decoded messages.JsonRPCMessage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	decoded.SessionID = msg.SessionID
+	m.out <- decoded
+	return nil
+}
+
+func (m *memoryTransport) Stop() error {
+	return nil
+}
+
+func (m *memoryTransport) send(t *testing.T, body string) {
+	t.Helper()
+	m.sendAs(t, "", body)
+}
+
+// sendAs delivers a message as if it arrived on the given session
+func (m *memoryTransport) sendAs(t *testing.T, sessionID string, body string) {
+	t.Helper()
+
+	var msg messages.JsonRPCMessage
+	if err := json.Unmarshal([]byte(body), &msg); err != nil {
+		t.Fatalf("failed to unmarshal message: %v", err)
+	}
+	msg.SessionID = sessionID
+	m.in <- msg
+}
+
+func (m *memoryTransport) receive(t *testing.T) messages.JsonRPCMessage {
+	t.Helper()
+
+	select {
+	case msg := <-m.out:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for a message from the server")
+	}
+	return messages.JsonRPCMessage{}
+}
+
+func startMemoryServer(t *testing.T, setup func(s *server.DefaultServer) *server.DefaultServer) *memoryTransport {
+	t.Helper()
+
+	config := server.NewDefaultConfig()
+	config.LogLevel = server.LogError
+	return startMemoryServerWithConfig(t, config, setup)
+}
+
+func startMemoryServerWithConfig(t *testing.T, config server.ServerConfig, setup func(s *server.DefaultServer) *server.DefaultServer) *memoryTransport {
+	t.Helper()
+
+	transport := newMemoryTransport()
+	runServer(t, transport, config, setup)
+	return transport
+}
+
+func runServer(t *testing.T, transport server.Transport, config server.ServerConfig, setup func(s *server.DefaultServer) *server.DefaultServer) {
+	t.Helper()
+
+	s := server.NewDefaultServerWithConfig(transport, server.ProtocolVersion20250326, "1.0.0", "TestServer", config)
+	if setup != nil {
+		s = setup(s)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Start(ctx)
+
+	t.Cleanup(func() {
+		s.Close()
+		cancel()
+	})
+}
+
+// initializeMemorySession runs the initialize handshake declaring the given client capabilities
+func initializeMemorySession(t *testing.T, transport *memoryTransport, capabilities string) {
+	t.Helper()
+	initializeMemorySessionAs(t, transport, "", capabilities)
+}
+
+// initializeMemorySessionAs runs the initialize handshake on the given session
+func initializeMemorySessionAs(t *testing.T, transport *memoryTransport, sessionID string, capabilities string) {
+	t.Helper()
+
+	transport.sendAs(t, sessionID, fmt.Sprintf(`{"jsonrpc":"2.0","id":"init","method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":%s,"clientInfo":{"name":"test","version":"1.0.0"}}}`, capabilities))
+	if response := transport.receive(t); response.Error != nil {
+		t.Fatalf("initialize failed: %+v", response.Error)
+	}
+	transport.sendAs(t, sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+}
+
+// toolText returns the text of the first content item of a tools/call response
+func toolText(t *testing.T, response messages.JsonRPCMessage) string {
+	t.Helper()
+
+	if response.Result == nil {
+		t.Fatalf("expected tool result, got %+v", response)
+	}
+	content, _ := (*response.Result)["content"].([]interface{})
+	if len(content) == 0 {
+		t.Fatalf("expected tool content, got %+v", *response.Result)
+	}
+	item, _ := content[0].(map[string]interface{})
+	text, _ := item["text"].(string)
+	return text
+}
+
+func TestProtocolVersionNegotiation(t *testing.T) {
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "version",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		info, _ := server.SessionInfoFromContext(ctx)
+		text := server.NegotiatedProtocolVersion(ctx) + " " + info.ClientInfo.Name
+		return server.ToolResult{
+			Content: []server.ToolCallContent{{Type: "text", Text: &text}},
+		}
+	})
+	setup := func(s *server.DefaultServer) *server.DefaultServer {
+		s = server.WithSupportedProtocolVersions(s, server.ProtocolVersion20241105)
+		s = server.WithToolsCapability(s, false, false)
+		return server.WithToolManager(s, &toolManager)
+	}
+
+	transport := startMemoryServer(t, setup)
+	transport.send(t, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{"sampling":{}},"clientInfo":{"name":"old","version":"1.0.0"}}}`)
+	response := transport.receive(t)
+	if response.Result == nil || (*response.Result)["protocolVersion"] != server.ProtocolVersion20241105 {
+		t.Fatalf("expected supported client version to be accepted, got %+v", response)
+	}
+	transport.send(t, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	transport.send(t, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"version","arguments":{}}}`)
+	response = transport.receive(t)
+	content := (*response.Result)["content"].([]interface{})[0].(map[string]interface{})
+	if text := content["text"]; text != "2024-11-05 old" {
+		t.Errorf("expected handler to see the negotiated version, got %v", text)
+	}
+
+	transport = startMemoryServer(t, setup)
+	transport.send(t, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"1999-01-01","capabilities":{},"clientInfo":{"name":"new","version":"1.0.0"}}}`)
+	response = transport.receive(t)
+	if response.Result == nil || (*response.Result)["protocolVersion"] != server.ProtocolVersion20250326 {
+		t.Errorf("expected latest version for unsupported client version, got %+v", response)
+	}
+
+	transport = startMemoryServer(t, setup)
+	transport.send(t, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	response = transport.receive(t)
+	if response.Error == nil || response.Error.Code != messages.JsonRPCErrorInvalidParams {
+		t.Errorf("expected invalid params error for missing protocol version, got %+v", response)
+	}
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	initialized := make(chan server.ClientInfo, 1)
+	transport := startMemoryServer(t, func(s *server.DefaultServer) *server.DefaultServer {
+		s.OnInitialized(func(clientInfo server.ClientInfo) {
+			initialized <- clientInfo
+		})
+		s = server.WithToolsCapability(s, false, false)
+		return server.WithToolManager(s, newTestToolManager())
+	})
+
+	transport.send(t, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	if response := transport.receive(t); response.Error == nil || response.Error.Code != messages.JsonRPCErrorInvalidRequest {
+		t.Errorf("expected tools/list before initialize to be rejected, got %+v", response)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	if response := transport.receive(t); response.Error != nil {
+		t.Errorf("expected ping before initialize to succeed, got %+v", response.Error)
+	}
+
+	transport.send(t, initializeBody)
+	if response := transport.receive(t); response.Error != nil {
+		t.Fatalf("initialize failed: %+v", response.Error)
+	}
+
+	// Between initialize and notifications/initialized only pings are allowed
+	transport.send(t, `{"jsonrpc":"2.0","id":"early","method":"tools/list"}`)
+	if response := transport.receive(t); response.Error == nil || response.Error.Code != messages.JsonRPCErrorInvalidRequest {
+		t.Errorf("expected tools/list before initialized to be rejected, got %+v", response)
+	}
+	transport.send(t, `{"jsonrpc":"2.0","id":"early-ping","method":"ping"}`)
+	if response := transport.receive(t); response.Error != nil {
+		t.Errorf("expected ping before initialized to succeed, got %+v", response.Error)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	select {
+	case clientInfo := <-initialized:
+		if clientInfo.Name != "test" {
+			t.Errorf("expected client info from initialize, got %+v", clientInfo)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("initialized hook was not called")
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`)
+	if response := transport.receive(t); response.Error != nil {
+		t.Errorf("expected tools/list after initialization to succeed, got %+v", response.Error)
+	}
+
+	transport.send(t, initializeBody)
+	if response := transport.receive(t); response.Error == nil || response.Error.Code != messages.JsonRPCErrorInvalidRequest {
+		t.Errorf("expected repeated initialize to be rejected, got %+v", response)
+	}
+}
+
+func TestMaxRequestActive(t *testing.T) {
+	release := make(chan struct{})
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "block",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		<-release
+		return server.ToolResult{Content: []server.ToolCallContent{}}
+	})
+
+	var s *server.DefaultServer
+	config := server.NewDefaultConfig()
+	config.LogLevel = server.LogError
+	config.MaxRequestActive = 1
+	config.MaxRequestQueued = 1
+	transport := startMemoryServerWithConfig(t, config, func(created *server.DefaultServer) *server.DefaultServer {
+		s = server.WithToolsCapability(created, false, false)
+		return server.WithToolManager(s, &toolManager)
+	})
+
+	transport.send(t, initializeBody)
+	transport.receive(t)
+	transport.send(t, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	call := `{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"block","arguments":{}}}`
+	transport.send(t, fmt.Sprintf(call, 2))
+	deadline := time.Now().Add(2 * time.Second)
+	for s.ActiveRequests() != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	transport.send(t, fmt.Sprintf(call, 3))
+	transport.send(t, fmt.Sprintf(call, 4))
+	response := transport.receive(t)
+	if response.Error == nil || response.Error.Code != messages.JsonRPCErrorServerBusy || response.ID != float64(4) {
+		t.Fatalf("expected request beyond the queue to be rejected, got %+v", response)
+	}
+	if queued := s.QueuedRequests(); queued != 1 {
+		t.Errorf("expected 1 queued request, got %d", queued)
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if response := transport.receive(t); response.Error != nil {
+			t.Errorf("expected blocked calls to complete, got %+v", response.Error)
+		}
+	}
+}
+
+func TestQueuedRequestsAnsweredOnClose(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "block",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		<-release
+		return server.ToolResult{Content: []server.ToolCallContent{}}
+	})
+
+	var s *server.DefaultServer
+	config := server.NewDefaultConfig()
+	config.LogLevel = server.LogError
+	config.MaxRequestActive = 1
+	config.MaxRequestQueued = 1
+	transport := startMemoryServerWithConfig(t, config, func(created *server.DefaultServer) *server.DefaultServer {
+		s = server.WithToolsCapability(created, false, false)
+		return server.WithToolManager(s, &toolManager)
+	})
+	initializeMemorySession(t, transport, `{}`)
+
+	call := `{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"block","arguments":{}}}`
+	transport.send(t, fmt.Sprintf(call, 2))
+	transport.send(t, fmt.Sprintf(call, 3))
+	deadline := time.Now().Add(2 * time.Second)
+	for (s.ActiveRequests() != 1 || s.QueuedRequests() != 1) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The queued call never gets a worker, it must not be left without a response
+	s.Close()
+	response := transport.receive(t)
+	if response.ID != float64(3) || response.Error == nil || response.Error.Code != messages.JsonRPCErrorInternalError {
+		t.Errorf("expected the queued call to be rejected on close, got %+v", response)
+	}
+	if queued := s.QueuedRequests(); queued != 0 {
+		t.Errorf("expected no queued requests after close, got %d", queued)
+	}
+}
+
+func TestRejectPolicyAdmitsSequentialRequests(t *testing.T) {
+	config := server.NewDefaultConfig()
+	config.LogLevel = server.LogError
+	config.MaxRequestActive = 2
+	config.RequestQueuePolicy = server.QueuePolicyReject
+	transport := startMemoryServerWithConfig(t, config, nil)
+
+	transport.send(t, initializeBody)
+	if response := transport.receive(t); response.Error != nil {
+		t.Fatalf("expected initialize to be admitted, got %+v", response.Error)
+	}
+
+	for i := 0; i < 500; i++ {
+		transport.send(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"ping"}`, i))
+		if response := transport.receive(t); response.Error != nil {
+			t.Fatalf("expected ping %d to be admitted with idle workers, got %+v", i, response.Error)
+		}
+	}
+}
+
+func TestSendRequest(t *testing.T) {
+	var s *server.DefaultServer
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "ask",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+
+		text := ""
+		result, err := s.SendRequest(ctx, "test/question", nil)
+		if err != nil {
+			text = err.Error()
+		} else {
+			text, _ = (*result)["answer"].(string)
+		}
+		return server.ToolResult{Content: []server.ToolCallContent{{Type: "text", Text: &text}}}
+	})
+
+	transport := startMemoryServer(t, func(created *server.DefaultServer) *server.DefaultServer {
+		s = server.WithToolsCapability(created, false, false)
+		return server.WithToolManager(s, &toolManager)
+	})
+
+	transport.send(t, initializeBody)
+	transport.receive(t)
+
+	call := `{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"ask","arguments":{}}}`
+	transport.send(t, fmt.Sprintf(call, 2))
+	if response := transport.receive(t); response.Error == nil || response.Error.Code != messages.JsonRPCErrorInvalidRequest {
+		t.Errorf("expected tools/call before initialized to be rejected, got %+v", response)
+	}
+	if _, err := s.SendRequestToSession(context.Background(), "", "test/question", nil); !errors.Is(err, server.ErrSessionNotReady) {
+		t.Errorf("expected requests to a session that is not ready to fail, got %v", err)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	transport.send(t, fmt.Sprintf(call, 3))
+	request := transport.receive(t)
+	if request.Method == nil || *request.Method != "test/question" {
+		t.Fatalf("expected server request, got %+v", request)
+	}
+	transport.send(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":%v,"result":{"answer":"42"}}`, request.ID))
+	if text := toolText(t, transport.receive(t)); text != "42" {
+		t.Errorf("expected the client's answer to reach the tool, got %q", text)
+	}
+
+	// Unanswered requests time out and are cancelled on the client
+	transport.send(t, fmt.Sprintf(call, 5))
+	request = transport.receive(t)
+	var cancelled bool
+	for i := 0; i < 2; i++ {
+		msg := transport.receive(t)
+		if msg.IsNotification() {
+			cancelled = *msg.Method == "notifications/cancelled" && (*msg.Params)["requestId"] == request.ID
+		} else if text := toolText(t, msg); !strings.Contains(text, "timed out") {
+			t.Errorf("expected timeout error, got %q", text)
+		}
+	}
+	if !cancelled {
+		t.Errorf("expected cancellation of request %v", request.ID)
+	}
+}
+
+func TestResponseWithInvalidID(t *testing.T) {
+	transport := startMemoryServer(t, nil)
+	initializeMemorySession(t, transport, `{}`)
+
+	transport.send(t, `{"jsonrpc":"2.0","id":[1],"result":{}}`)
+	transport.send(t, `{"jsonrpc":"2.0","id":{"x":1},"error":{"code":-32603,"message":"boom"}}`)
+
+	transport.send(t, `{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+	if response := transport.receive(t); response.Error != nil || response.ID != float64(1) {
+		t.Errorf("expected the server to survive invalid response IDs, got %+v", response)
+	}
+}
+
+func TestResponseFromOtherSession(t *testing.T) {
+	var s *server.DefaultServer
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "ask",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		text := ""
+		result, err := s.SendRequest(ctx, "test/question", nil)
+		if err != nil {
+			text = err.Error()
+		} else {
+			text, _ = (*result)["answer"].(string)
+		}
+		return server.ToolResult{Content: []server.ToolCallContent{{Type: "text", Text: &text}}}
+	})
+
+	transport := startMemoryServer(t, func(created *server.DefaultServer) *server.DefaultServer {
+		s = server.WithToolsCapability(created, false, false)
+		return server.WithToolManager(s, &toolManager)
+	})
+	initializeMemorySessionAs(t, transport, "a", `{}`)
+	initializeMemorySessionAs(t, transport, "b", `{}`)
+
+	transport.sendAs(t, "a", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"ask","arguments":{}}}`)
+	request := transport.receive(t)
+	if request.Method == nil || *request.Method != "test/question" || request.SessionID != "a" {
+		t.Fatalf("expected server request to session a, got %+v", request)
+	}
+
+	transport.sendAs(t, "b", fmt.Sprintf(`{"jsonrpc":"2.0","id":%v,"result":{"answer":"forged"}}`, request.ID))
+	transport.sendAs(t, "a", fmt.Sprintf(`{"jsonrpc":"2.0","id":%v,"result":{"answer":"42"}}`, request.ID))
+	if text := toolText(t, transport.receive(t)); text != "42" {
+		t.Errorf("expected only session a's answer to reach the tool, got %q", text)
+	}
+}
+
+func TestCreateMessage(t *testing.T) {
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "summarize",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		result, err := server.CreateMessage(ctx, server.CreateMessageRequest{
+			Messages:     []server.SamplingMessage{server.NewTextSamplingMessage(server.RoleUser, "Summarize this")},
+			SystemPrompt: "Be brief",
+			MaxTokens:    100,
+		})
+		text := ""
+		if err != nil {
+			text = err.Error()
+		} else {
+			text = *result.Content.Text + " by " + result.Model
+		}
+		return server.ToolResult{Content: []server.ToolCallContent{{Type: "text", Text: &text}}}
+	})
+	setup := func(s *server.DefaultServer) *server.DefaultServer {
+		s = server.WithToolsCapability(s, false, false)
+		return server.WithToolManager(s, &toolManager)
+	}
+	call := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"summarize","arguments":{}}}`
+
+	transport := startMemoryServer(t, setup)
+	initializeMemorySession(t, transport, `{}`)
+	transport.send(t, call)
+	if text := toolText(t, transport.receive(t)); text != server.ErrSamplingNotSupported.Error() {
+		t.Errorf("expected sampling to be refused without the capability, got %q", text)
+	}
+
+	transport = startMemoryServer(t, setup)
+	initializeMemorySession(t, transport, `{"sampling":{}}`)
+	transport.send(t, call)
+	request := transport.receive(t)
+	if request.Method == nil || *request.Method != "sampling/createMessage" || (*request.Params)["maxTokens"] != float64(100) {
+		t.Fatalf("expected sampling request, got %+v", request)
+	}
+	transport.send(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":%v,"result":{"role":"assistant","content":{"type":"text","text":"Short"},"model":"test-model","stopReason":"endTurn"}}`, request.ID))
+	if text := toolText(t, transport.receive(t)); text != "Short by test-model" {
+		t.Errorf("expected sampled text, got %q", text)
+	}
+}
+
+func TestListRoots(t *testing.T) {
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "roots",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		roots, err := server.ListRoots(ctx)
+		text := ""
+		if err != nil {
+			text = err.Error()
+		}
+		for _, root := range roots {
+			text += root.URI
+		}
+		return server.ToolResult{Content: []server.ToolCallContent{{Type: "text", Text: &text}}}
+	})
+
+	changed := make(chan []server.Root, 1)
+	transport := startMemoryServer(t, func(s *server.DefaultServer) *server.DefaultServer {
+		s.OnRootsChanged(func(info server.SessionInfo, roots []server.Root) {
+			changed <- roots
+		})
+		s = server.WithToolsCapability(s, false, false)
+		return server.WithToolManager(s, &toolManager)
+	})
+	initializeMemorySession(t, transport, `{"roots":{"listChanged":true}}`)
+
+	answerRoots := func(uri string) {
+		request := transport.receive(t)
+		if request.Method == nil || *request.Method != "roots/list" {
+			t.Fatalf("expected roots/list request, got %+v", request)
+		}
+		transport.send(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":%v,"result":{"roots":[{"uri":%q}]}}`, request.ID, uri))
+	}
+
+	call := `{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"roots","arguments":{}}}`
+	transport.send(t, fmt.Sprintf(call, 1))
+	answerRoots("file:///first")
+	if text := toolText(t, transport.receive(t)); text != "file:///first" {
+		t.Errorf("expected roots from the client, got %q", text)
+	}
+
+	// The second call is served from the cache without asking the client
+	transport.send(t, fmt.Sprintf(call, 2))
+	if text := toolText(t, transport.receive(t)); text != "file:///first" {
+		t.Errorf("expected cached roots, got %q", text)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","method":"notifications/roots/list_changed"}`)
+	answerRoots("file:///second")
+	select {
+	case roots := <-changed:
+		if len(roots) != 1 || roots[0].URI != "file:///second" {
+			t.Errorf("expected refreshed roots in hook, got %+v", roots)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("roots changed hook was not called")
+	}
+
+	transport.send(t, fmt.Sprintf(call, 3))
+	if text := toolText(t, transport.receive(t)); text != "file:///second" {
+		t.Errorf("expected refreshed roots to be cached, got %q", text)
+	}
+
+	// A refresh overtaken by another change answers last but must not replace the newer roots in the cache
+	transport.send(t, `{"jsonrpc":"2.0","method":"notifications/roots/list_changed"}`)
+	stale := transport.receive(t)
+	transport.send(t, `{"jsonrpc":"2.0","method":"notifications/roots/list_changed"}`)
+	answerRoots("file:///third")
+	<-changed
+	transport.send(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":%v,"result":{"roots":[{"uri":"file:///stale"}]}}`, stale.ID))
+	<-changed
+
+	transport.send(t, fmt.Sprintf(call, 4))
+	if text := toolText(t, transport.receive(t)); text != "file:///third" {
+		t.Errorf("expected the newest roots to stay cached, got %q", text)
+	}
+}
+
+func TestElicit(t *testing.T) {
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "confirm",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		result, err := server.Elicit(ctx, "Which environment?", map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"environment": map[string]interface{}{"type": "string", "enum": []string{"staging", "production"}},
+				"replicas":    map[string]interface{}{"type": "integer", "minimum": 1},
+			},
+			"required": []string{"environment"},
+		})
+		text := ""
+		if err != nil {
+			text = err.Error()
+		} else {
+			text = fmt.Sprintf("%s %v", result.Action, result.Content["environment"])
+		}
+		return server.ToolResult{Content: []server.ToolCallContent{{Type: "text", Text: &text}}}
+	})
+	setup := func(s *server.DefaultServer) *server.DefaultServer {
+		s = server.WithToolsCapability(s, false, false)
+		return server.WithToolManager(s, &toolManager)
+	}
+	call := `{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"confirm","arguments":{}}}`
+
+	transport := startMemoryServer(t, setup)
+	initializeMemorySession(t, transport, `{}`)
+	transport.send(t, fmt.Sprintf(call, 1))
+	if text := toolText(t, transport.receive(t)); text != server.ErrElicitationNotSupported.Error() {
+		t.Errorf("expected elicitation to be refused without the capability, got %q", text)
+	}
+
+	transport = startMemoryServer(t, setup)
+	initializeMemorySession(t, transport, `{"elicitation":{}}`)
+	cases := []struct {
+		result   string
+		expected string
+	}{
+		{`{"action":"accept","content":{"environment":"staging","replicas":2}}`, "accept staging"},
+		{`{"action":"accept","content":{"environment":"qa","replicas":0.5}}`, "invalid elicited content: environment must be one of the allowed values, replicas must be an integer"},
+		{`{"action":"decline"}`, "decline <nil>"},
+	}
+	for i, c := range cases {
+		transport.send(t, fmt.Sprintf(call, i+2))
+		request := transport.receive(t)
+		if request.Method == nil || *request.Method != "elicitation/create" {
+			t.Fatalf("expected elicitation request, got %+v", request)
+		}
+		transport.send(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":%v,"result":%s}`, request.ID, c.result))
+		if text := toolText(t, transport.receive(t)); text != c.expected {
+			t.Errorf("expected %q, got %q", c.expected, text)
+		}
+	}
+}
+
+func TestProgressReporter(t *testing.T) {
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "work",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		progress := server.ProgressFromContext(ctx)
+		text := "done"
+		if progress == nil {
+			text = "no progress"
+		}
+		progress.Report(ctx, 1, 3, "started")
+		progress.Report(ctx, 2, 3, "")
+		if err := progress.Report(ctx, 2, 3, ""); progress != nil && !errors.Is(err, server.ErrProgressNotIncreasing) {
+			text = fmt.Sprintf("expected monotonic check, got %v", err)
+		}
+		progress.Report(ctx, 3, 3, "finished")
+		return server.ToolResult{Content: []server.ToolCallContent{{Type: "text", Text: &text}}}
+	})
+
+	transport := startMemoryServer(t, func(s *server.DefaultServer) *server.DefaultServer {
+		s = server.WithToolsCapability(s, false, false)
+		return server.WithToolManager(s, &toolManager)
+	})
+	initializeMemorySession(t, transport, `{}`)
+
+	transport.send(t, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"work","arguments":{},"_meta":{"progressToken":7}}}`)
+	expected := []string{"started", "finished"}
+	for _, message := range expected {
+		notification := transport.receive(t)
+		if notification.Method == nil || *notification.Method != "notifications/progress" {
+			t.Fatalf("expected progress notification, got %+v", notification)
+		}
+		params := *notification.Params
+		if params["progressToken"] != float64(7) || params["message"] != message {
+			t.Errorf("expected progress %q for token 7, got %+v", message, params)
+		}
+	}
+
+	if text := toolText(t, transport.receive(t)); text != "done" {
+		t.Errorf("unexpected tool result %q", text)
+	}
+
+	// Without a progress token the reporter is nil and reporting sends nothing
+	transport.send(t, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"work","arguments":{}}}`)
+	if text := toolText(t, transport.receive(t)); !strings.HasPrefix(text, "no progress") {
+		t.Errorf("unexpected tool result %q", text)
+	}
+}
+
+func TestProgressWithoutTotalFlushesLastUpdate(t *testing.T) {
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "scan",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		progress := server.ProgressFromContext(ctx)
+		progress.Report(ctx, 1, 0, "first")
+		progress.Report(ctx, 2, 0, "middle")
+		progress.Report(ctx, 3, 0, "last")
+		text := "done"
+		return server.ToolResult{Content: []server.ToolCallContent{{Type: "text", Text: &text}}}
+	})
+
+	transport := startMemoryServer(t, func(s *server.DefaultServer) *server.DefaultServer {
+		s = server.WithToolsCapability(s, false, false)
+		return server.WithToolManager(s, &toolManager)
+	})
+	initializeMemorySession(t, transport, `{}`)
+
+	// Without a total the last update looks like any other, it is sent once the request finishes
+	transport.send(t, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"scan","arguments":{},"_meta":{"progressToken":"s"}}}`)
+	for _, message := range []string{"first", "last"} {
+		notification := transport.receive(t)
+		if notification.Method == nil || *notification.Method != "notifications/progress" || (*notification.Params)["message"] != message {
+			t.Fatalf("expected progress %q, got %+v", message, notification)
+		}
+	}
+	if text := toolText(t, transport.receive(t)); text != "done" {
+		t.Errorf("unexpected tool result %q", text)
+	}
+}
+
+func TestProgressSendsHeldBackUpdateAfterInterval(t *testing.T) {
+	release := make(chan struct{})
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "wait",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		progress := server.ProgressFromContext(ctx)
+		progress.Report(ctx, 1, 0, "first")
+		progress.Report(ctx, 2, 0, "second")
+		<-release
+		text := "done"
+		return server.ToolResult{Content: []server.ToolCallContent{{Type: "text", Text: &text}}}
+	})
+
+	config := server.NewDefaultConfig()
+	config.LogLevel = server.LogError
+	config.ProgressIntervalMilliseconds = 50
+	transport := startMemoryServerWithConfig(t, config, func(s *server.DefaultServer) *server.DefaultServer {
+		s = server.WithToolsCapability(s, false, false)
+		return server.WithToolManager(s, &toolManager)
+	})
+	initializeMemorySession(t, transport, `{}`)
+
+	// The held back update must not wait for a handler that keeps running
+	transport.send(t, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"wait","arguments":{},"_meta":{"progressToken":"w"}}}`)
+	for _, message := range []string{"first", "second"} {
+		notification := transport.receive(t)
+		if notification.Method == nil || *notification.Method != "notifications/progress" || (*notification.Params)["message"] != message {
+			t.Fatalf("expected progress %q while the tool runs, got %+v", message, notification)
+		}
+	}
+
+	close(release)
+	if text := toolText(t, transport.receive(t)); text != "done" {
+		t.Errorf("unexpected tool result %q", text)
+	}
+}
+
+func TestClientLogging(t *testing.T) {
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "noisy",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		logger := server.LoggerFromContext(ctx).Named("database")
+		logger.Info("connecting to %s", "primary")
+		logger.Log(server.LoggingError, map[string]interface{}{"error": "connection refused", "attempt": 3})
+		text := "done"
+		return server.ToolResult{Content: []server.ToolCallContent{{Type: "text", Text: &text}}}
+	})
+	returned := make(chan struct{})
+	toolManager.AddTool(server.Tool{
+		Name:        "background",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		logger := server.LoggerFromContext(ctx)
+		go func() {
+			<-returned
+			logger.Error("finished in the background")
+		}()
+		text := "started"
+		return server.ToolResult{Content: []server.ToolCallContent{{Type: "text", Text: &text}}}
+	})
+
+	transport := startMemoryServer(t, func(s *server.DefaultServer) *server.DefaultServer {
+		s = server.WithLoggingCapability(s)
+		s = server.WithToolsCapability(s, false, false)
+		return server.WithToolManager(s, &toolManager)
+	})
+	initializeMemorySession(t, transport, `{}`)
+
+	transport.send(t, `{"jsonrpc":"2.0","id":1,"method":"logging/setLevel","params":{"level":"verbose"}}`)
+	if response := transport.receive(t); response.Error == nil || response.Error.Code != messages.JsonRPCErrorInvalidParams {
+		t.Errorf("expected unknown level to be rejected, got %+v", response)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":2,"method":"logging/setLevel","params":{"level":"warning"}}`)
+	if response := transport.receive(t); response.Error != nil {
+		t.Fatalf("failed to set level: %+v", response.Error)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"noisy","arguments":{}}}`)
+	notification := transport.receive(t)
+	if notification.Method == nil || *notification.Method != "notifications/message" {
+		t.Fatalf("expected log notification, got %+v", notification)
+	}
+	params := *notification.Params
+	data, _ := params["data"].(map[string]interface{})
+	if params["level"] != "error" || params["logger"] != "database" || data["attempt"] != float64(3) {
+		t.Errorf("unexpected log notification %+v", params)
+	}
+
+	if text := toolText(t, transport.receive(t)); text != "done" {
+		t.Errorf("expected info record to stay local, got %q", text)
+	}
+
+	// Records logged after the handler returned still reach the client
+	transport.send(t, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"background","arguments":{}}}`)
+	if text := toolText(t, transport.receive(t)); text != "started" {
+		t.Fatalf("unexpected tool result %q", text)
+	}
+	close(returned)
+	notification = transport.receive(t)
+	if notification.Method == nil || *notification.Method != "notifications/message" || (*notification.Params)["data"] != "finished in the background" {
+		t.Errorf("expected the background record to be forwarded, got %+v", notification)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	promptManager := server.NewPromptManager()
+	promptManager.AddPrompt(server.Prompt{
+		Name:      "deploy",
+		Arguments: []server.PromptArgument{{Name: "service", Required: true}, {Name: "note"}},
+	}, func(ctx context.Context, name string, arguments map[string]string) (server.PromptResult, error) {
+		return server.PromptResult{}, nil
+	})
+	err := promptManager.AddArgumentCompletion("deploy", "service", func(ctx context.Context, value string, arguments map[string]string) (server.CompletionResult, error) {
+		values := make([]string, 0)
+		for i := 0; i < 150; i++ {
+			values = append(values, fmt.Sprintf("%s-%d", value, i))
+		}
+		return server.CompletionResult{Values: values}, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to add prompt completion: %v", err)
+	}
+	if err := promptManager.AddArgumentCompletion("deploy", "region", nil); !errors.Is(err, server.ErrUnknownCompletionArgument) {
+		t.Errorf("expected undeclared argument to be refused, got %v", err)
+	}
+
+	resourceManager := server.NewResourceManager()
+	resourceManager.AddResourceTemplate(server.ResourceTemplate{URITemplate: "db://{database}/tables/{table}", Name: "table"},
+		func(ctx context.Context, uri string, variables map[string]string) ([]server.ResourceContents, error) {
+			return nil, nil
+		})
+	err = resourceManager.AddTemplateCompletion("db://{database}/tables/{table}", "table", func(ctx context.Context, value string, arguments map[string]string) (server.CompletionResult, error) {
+		return server.CompletionResult{Values: []string{arguments["database"] + "." + value + "s"}}, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to add template completion: %v", err)
+	}
+
+	transport := startMemoryServer(t, func(s *server.DefaultServer) *server.DefaultServer {
+		s = server.WithCompletionsCapability(s)
+		s = server.WithPromptManager(s, &promptManager)
+		return server.WithResourceManager(s, &resourceManager)
+	})
+	initializeMemorySession(t, transport, `{}`)
+
+	completion := func(response messages.JsonRPCMessage) map[string]interface{} {
+		if response.Result == nil {
+			t.Fatalf("expected completion result, got %+v", response)
+		}
+		result, _ := (*response.Result)["completion"].(map[string]interface{})
+		return result
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":1,"method":"completion/complete","params":{"ref":{"type":"ref/prompt","name":"deploy"},"argument":{"name":"service","value":"api"}}}`)
+	result := completion(transport.receive(t))
+	if values := result["values"].([]interface{}); len(values) != 100 || values[0] != "api-0" || result["total"] != float64(150) || result["hasMore"] != true {
+		t.Errorf("expected 100 of 150 values, got %d values, total %v, hasMore %v", len(values), result["total"], result["hasMore"])
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":2,"method":"completion/complete","params":{"ref":{"type":"ref/prompt","name":"deploy"},"argument":{"name":"note","value":""}}}`)
+	if values := completion(transport.receive(t))["values"].([]interface{}); len(values) != 0 {
+		t.Errorf("expected no values without a provider, got %v", values)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":3,"method":"completion/complete","params":{"ref":{"type":"ref/resource","uri":"db://{database}/tables/{table}"},"argument":{"name":"table","value":"user"},"context":{"arguments":{"database":"main"}}}}`)
+	if values := completion(transport.receive(t))["values"].([]interface{}); len(values) != 1 || values[0] != "main.users" {
+		t.Errorf("expected completion using resolved arguments, got %v", values)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":4,"method":"completion/complete","params":{"ref":{"type":"ref/prompt","name":"missing"},"argument":{"name":"service","value":""}}}`)
+	if response := transport.receive(t); response.Error == nil || response.Error.Code != messages.JsonRPCErrorInvalidParams {
+		t.Errorf("expected unknown prompt to be rejected, got %+v", response)
+	}
+}
+
+func TestToolArgumentValidation(t *testing.T) {
+	toolManager := newTestToolManager()
+	toolManager.AddTool(server.Tool{
+		Name: "greet",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"name": map[string]interface{}{"type": "string"}},
+			"required":   []interface{}{"name"},
+		},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		return server.ToolResult{}
+	})
+	transport := startMemoryServer(t, func(s *server.DefaultServer) *server.DefaultServer {
+		s = server.WithToolsCapability(s, false, false)
+		return server.WithToolManager(s, toolManager)
+	})
+	initializeMemorySession(t, transport, `{}`)
+
+	violations := func(response messages.JsonRPCMessage) []interface{} {
+		t.Helper()
+		if response.Result == nil || (*response.Result)["isError"] != true {
+			t.Fatalf("expected tool error result, got %+v", response)
+		}
+		data, _ := (*response.Result)["structuredContent"].(map[string]interface{})
+		violations, _ := data["violations"].([]interface{})
+		return violations
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"text":7}}}`)
+	response := transport.receive(t)
+	if found := violations(response); len(found) != 1 || found[0].(map[string]interface{})["path"] != "/text" {
+		t.Errorf("expected violation at /text, got %v", found)
+	}
+	if text := toolText(t, response); !strings.HasPrefix(text, "invalid arguments for tool echo") {
+		t.Errorf("expected violations in the text content, got %q", text)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":2,"method":"tools/call"}`)
+	if response := transport.receive(t); response.Error == nil || response.Error.Code != messages.JsonRPCErrorInvalidParams {
+		t.Errorf("expected missing params to be invalid params, got %+v", response)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"greet"}}`)
+	if found := violations(transport.receive(t)); len(found) != 1 || found[0].(map[string]interface{})["path"] != "/name" {
+		t.Errorf("expected required violation for name, got %v", found)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"echo"}}`)
+	if response := transport.receive(t); response.Error != nil || toolText(t, response) != "" {
+		t.Errorf("expected missing arguments to call the tool with none, got %+v", response)
+	}
+}
+
+func TestCancellationIsScopedToSession(t *testing.T) {
+	started := make(chan string, 2)
+	release := make(chan struct{})
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "wait",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		info, _ := server.SessionInfoFromContext(ctx)
+		started <- info.ID
+		text := "released"
+		select {
+		case <-ctx.Done():
+			text = "cancelled"
+		case <-release:
+		}
+		return server.ToolResult{Content: []server.ToolCallContent{{Type: "text", Text: &text}}}
+	})
+
+	transport := startMemoryServer(t, func(s *server.DefaultServer) *server.DefaultServer {
+		s = server.WithToolsCapability(s, false, false)
+		return server.WithToolManager(s, &toolManager)
+	})
+	initializeMemorySessionAs(t, transport, "a", `{}`)
+	initializeMemorySessionAs(t, transport, "b", `{}`)
+
+	// Both sessions use the same request ID, b registers first so a shared key would cancel a's request
+	for _, sessionID := range []string{"b", "a"} {
+		transport.sendAs(t, sessionID, `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"wait","arguments":{}}}`)
+		select {
+		case <-started:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("tool call of session %q did not start", sessionID)
+		}
+	}
+
+	transport.sendAs(t, "b", `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7}}`)
+	response := transport.receive(t)
+	if response.SessionID != "b" || toolText(t, response) != "cancelled" {
+		t.Fatalf("expected only session b's request to be cancelled, got %+v", response)
+	}
+
+	close(release)
+	response = transport.receive(t)
+	if response.SessionID != "a" || toolText(t, response) != "released" {
+		t.Errorf("expected session a's request to finish normally, got %+v", response)
+	}
+}
+
+func TestRequestWithInvalidID(t *testing.T) {
+	transport := startMemoryServer(t, nil)
+	initializeMemorySession(t, transport, `{}`)
+
+	transport.send(t, `{"jsonrpc":"2.0","id":{"x":1},"method":"ping"}`)
+	response := transport.receive(t)
+	if response.ID != nil || response.Error == nil || response.Error.Code != messages.JsonRPCErrorInvalidRequest {
+		t.Errorf("expected invalid request error with null id, got %+v", response)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	if response := transport.receive(t); response.Error != nil || response.ID != float64(2) {
+		t.Errorf("expected the server to keep answering, got %+v", response)
+	}
+}
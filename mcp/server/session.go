@@ -0,0 +1,324 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+)
+
+type ctxSessionKey struct{}
+
+type ClientInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type SessionState int
+
+const (
+	SessionUninitialized SessionState = iota
+	SessionInitializing
+	SessionReady
+	SessionShuttingDown
+)
+
+func (state SessionState) String() string {
+	switch state {
+	case SessionUninitialized:
+		return "uninitialized"
+	case SessionInitializing:
+		return "initializing"
+	case SessionReady:
+		return "ready"
+	case SessionShuttingDown:
+		return "shutting down"
+	default:
+		return fmt.Sprintf("SessionState(%d)", int(state))
+	}
+}
+
+// SessionInfo is what the server learned about a client during initialize
+type SessionInfo struct {
+	ID                 string
+	State              SessionState
+	ProtocolVersion    string
+	ClientInfo         ClientInfo
+	ClientCapabilities messages.ClientCapabilities
+}
+
+// session holds the state the server keeps for one connected client
+type session struct {
+	id            string
+	info          SessionInfo
+	subscriptions map[string]bool
+	roots         []Root
+	rootsCached   bool
+	rootsVersion  uint64
+	level         LoggingLevel
+	pinging       bool
+	mutex         sync.RWMutex
+}
+
+func newSession(id string) *session {
+	return &session{
+		id:            id,
+		info:          SessionInfo{ID: id},
+		subscriptions: make(map[string]bool),
+	}
+}
+
+// beginPing marks a server ping to the session as in flight, it reports false when one already is
+func (s *session) beginPing() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.pinging {
+		return false
+	}
+	s.pinging = true
+	return true
+}
+
+func (s *session) endPing() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.pinging = false
+}
+
+// beginInitialize records the client's initialize params, it fails when the session went past uninitialized
+func (s *session) beginInitialize(info SessionInfo) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.info.State != SessionUninitialized {
+		return fmt.Errorf("session is already %s", s.info.State)
+	}
+
+	info.ID = s.id
+	info.State = SessionInitializing
+	s.info = info
+	return nil
+}
+
+// markReady moves an initializing session to ready and reports whether it did
+func (s *session) markReady() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.info.State != SessionInitializing {
+		return false
+	}
+	s.info.State = SessionReady
+	return true
+}
+
+func (s *session) markShuttingDown() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.info.State = SessionShuttingDown
+}
+
+func (s *session) state() SessionState {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.info.State
+}
+
+func (s *session) sessionInfo() SessionInfo {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.info
+}
+
+func (s *session) subscribe(uri string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.subscriptions[uri] = true
+}
+
+func (s *session) unsubscribe(uri string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.subscriptions, uri)
+}
+
+func (s *session) clearSubscriptions() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.subscriptions = make(map[string]bool)
+}
+
+func (s *session) isSubscribed(uri string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.subscriptions[uri]
+}
+
+func (s *session) setLogLevel(level LoggingLevel) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.level = level
+}
+
+// logLevel returns the level the client selected, nothing is forwarded before it selects one
+func (s *session) logLevel() (LoggingLevel, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.level, s.level != ""
+}
+
+// rootsGeneration changes every time the roots are invalidated
+func (s *session) rootsGeneration() uint64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.rootsVersion
+}
+
+// setRoots caches roots fetched in generation, a fetch that was overtaken by invalidateRoots is not cached
+func (s *session) setRoots(roots []Root, generation uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if generation != s.rootsVersion {
+		return
+	}
+	s.roots = roots
+	s.rootsCached = true
+}
+
+func (s *session) cachedRoots() ([]Root, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.roots, s.rootsCached
+}
+
+func (s *session) invalidateRoots() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.roots = nil
+	s.rootsCached = false
+	s.rootsVersion++
+}
+
+func sessionFromContext(ctx context.Context) *session {
+	current, _ := ctx.Value(ctxSessionKey{}).(*session)
+	return current
+}
+
+// SessionInfoFromContext returns the session of the request being handled, the protocol version is empty before initialize
+func SessionInfoFromContext(ctx context.Context) (SessionInfo, bool) {
+	current := sessionFromContext(ctx)
+	if current == nil {
+		return SessionInfo{}, false
+	}
+	return current.sessionInfo(), true
+}
+
+// NegotiatedProtocolVersion returns the protocol version agreed with the client of the request being handled
+func NegotiatedProtocolVersion(ctx context.Context) string {
+	info, _ := SessionInfoFromContext(ctx)
+	return info.ProtocolVersion
+}
+
+// lookupSession returns the session with the given ID or nil, unlike sessionFor it never brings a removed session back
+func (s *DefaultServer) lookupSession(sessionID string) *session {
+	s.sessionsMutex.RLock()
+	defer s.sessionsMutex.RUnlock()
+
+	return s.sessions[sessionID]
+}
+
+// sessionFor returns the session with the given ID, creating it on first use, only messages from clients may call it
+func (s *DefaultServer) sessionFor(sessionID string) *session {
+	s.sessionsMutex.RLock()
+	current, exist := s.sessions[sessionID]
+	s.sessionsMutex.RUnlock()
+	if exist {
+		return current
+	}
+
+	s.sessionsMutex.Lock()
+	defer s.sessionsMutex.Unlock()
+
+	if current, exist = s.sessions[sessionID]; !exist {
+		current = newSession(sessionID)
+		s.sessions[sessionID] = current
+		s.logger.Debug("Session started: %q", sessionID)
+	}
+	return current
+}
+
+func (s *DefaultServer) removeSession(sessionID string) {
+	s.sessionsMutex.Lock()
+	defer s.sessionsMutex.Unlock()
+
+	if current, exist := s.sessions[sessionID]; exist {
+		current.markShuttingDown()
+		current.clearSubscriptions()
+		delete(s.sessions, sessionID)
+		s.logger.Debug("Session removed: %q", sessionID)
+	}
+}
+
+// Sessions returns the sessions the server currently holds state for
+func (s *DefaultServer) Sessions() []SessionInfo {
+	sessions := s.allSessions()
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, current := range sessions {
+		infos = append(infos, current.sessionInfo())
+	}
+	return infos
+}
+
+func (s *DefaultServer) allSessions() []*session {
+	s.sessionsMutex.RLock()
+	defer s.sessionsMutex.RUnlock()
+
+	sessions := make([]*session, 0, len(s.sessions))
+	for _, current := range s.sessions {
+		sessions = append(sessions, current)
+	}
+	return sessions
+}
+
+// relatedRequestID returns the ID of the request handled in ctx, but only for messages to the session that sent it
+func relatedRequestID(ctx context.Context, sessionID string) interface{} {
+	current := sessionFromContext(ctx)
+	if current == nil || current.id != sessionID {
+		return nil
+	}
+	return ctx.Value(ctxRequestIdKey{})
+}
+
+// sendNotification writes a notification to one session and forgets the session if the transport no longer knows it
+func (s *DefaultServer) sendNotification(ctx context.Context, sessionID string, notification *messages.Notification) error {
+	msg := notification.ToJsonRPCMessage()
+	msg.SessionID = sessionID
+	msg.RelatedRequestID = relatedRequestID(ctx, sessionID)
+
+	withTimeoutCtx, cancel := context.WithTimeout(ctx, s.config.OutgoingMessageTimeoutSeconds*time.Second)
+	defer cancel()
+
+	err := s.transport.Write(*msg, withTimeoutCtx)
+	if errors.Is(err, ErrSessionNotFound) {
+		s.removeSession(sessionID)
+	}
+	return err
+}
@@ -2,88 +2,325 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sync"
 )
 
 type Tool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
-	Annotations interface{}            `json:"annotations,omitempty"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	InputSchema  map[string]interface{} `json:"inputSchema"`
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+	Annotations  interface{}            `json:"annotations,omitempty"`
 }
 
+// ToolCallContent is a content block of any type, see content.go for constructors
 type ToolCallContent struct {
-	Type     string  `json:"type"`
-	Text     *string `json:"text,omitempty"`
-	Data     *string `json:"data,omitempty"` // base64-encoded string
-	MimeType *string `json:"mimeType,omitempty"`
+	Type        string            `json:"type"`
+	Text        *string           `json:"text,omitempty"`
+	Data        *string           `json:"data,omitempty"` // base64-encoded string
+	MimeType    *string           `json:"mimeType,omitempty"`
+	Resource    *ResourceContents `json:"resource,omitempty"`
+	URI         string            `json:"uri,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Size        *int64            `json:"size,omitempty"`
+	Annotations *Annotations      `json:"annotations,omitempty"`
 }
 
 type ToolResult struct {
-	Content []ToolCallContent
-	IsError bool
+	Content           []ToolCallContent
+	StructuredContent interface{} // Must conform to the tool's OutputSchema when it declares one
+	IsError           bool
 }
 
 type ToolCallback func(context.Context, string, map[string]interface{}) ToolResult
 type ToolCallbacksMap map[string]ToolCallback
 
+// toolValidator keeps the schema errors of a tool so that calls can report them
+type toolValidator struct {
+	input     *SchemaValidator
+	inputErr  error
+	output    *SchemaValidator
+	outputErr error
+}
+
+var ErrToolNotFound = errors.New("tool not found")
+
+// ToolManager may be changed while calls are in flight, calls that already started keep the callback they found
 type ToolManager struct {
+	mutex         *sync.RWMutex
 	tools         []Tool
 	toolCallbacks ToolCallbacksMap
+	validators    map[string]toolValidator
+	disabled      map[string]bool
+	listeners     []func()
 }
 
-func (t *ToolManager) findTool(name string) (*Tool, *ToolCallback, error) {
-	var tool *Tool
-	for _, toolDef := range t.tools {
-		if toolDef.Name == name {
-			tool = &toolDef
-		}
-	}
+// toolEntry is a consistent snapshot of a tool, a call uses it throughout even if the tool is replaced meanwhile
+type toolEntry struct {
+	tool      Tool
+	callback  ToolCallback
+	validator toolValidator
+}
+
+func (t *ToolManager) findTool(name string) (toolEntry, error) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
 
-	if tool == nil {
-		return nil, nil, errors.New("tool not found")
+	index := t.indexOf(name)
+	if index < 0 {
+		return toolEntry{}, ErrToolNotFound
+	}
+	if t.disabled[name] {
+		return toolEntry{}, fmt.Errorf("tool %s is disabled", name)
 	}
 
 	callback, exist := t.toolCallbacks[name]
 	if !exist {
-		return nil, nil, errors.New("can't execute tool, no callback")
+		return toolEntry{}, errors.New("can't execute tool, no callback")
+	}
+
+	return toolEntry{
+		tool:      t.tools[index],
+		callback:  callback,
+		validator: t.validators[name],
+	}, nil
+}
+
+func (t *ToolManager) indexOf(name string) int {
+	for i, toolDef := range t.tools {
+		if toolDef.Name == name {
+			return i
+		}
 	}
+	return -1
+}
 
-	return tool, &callback, nil
+func newToolValidator(definition Tool) toolValidator {
+	entry := toolValidator{}
+	entry.input, entry.inputErr = NewSchemaValidator(definition.InputSchema)
+	if definition.OutputSchema != nil {
+		entry.output, entry.outputErr = NewSchemaValidator(definition.OutputSchema)
+	}
+	return entry
 }
 
 func (t *ToolManager) AddTool(definition Tool, callback ToolCallback) {
+	entry := newToolValidator(definition)
+
+	t.mutex.Lock()
 	t.tools = append(t.tools, definition)
 	t.toolCallbacks[definition.Name] = callback
+	t.validators[definition.Name] = entry
+	t.mutex.Unlock()
+
+	t.notifyListChanged()
+}
+
+// ReplaceTool swaps the definition and callback of a registered tool, keeping its position and enabled state
+func (t *ToolManager) ReplaceTool(definition Tool, callback ToolCallback) error {
+	entry := newToolValidator(definition)
+
+	t.mutex.Lock()
+	index := t.indexOf(definition.Name)
+	if index < 0 {
+		t.mutex.Unlock()
+		return fmt.Errorf("failed to replace tool %s: %w", definition.Name, ErrToolNotFound)
+	}
+	t.tools[index] = definition
+	t.toolCallbacks[definition.Name] = callback
+	t.validators[definition.Name] = entry
+	t.mutex.Unlock()
+
+	t.notifyListChanged()
+	return nil
+}
+
+func (t *ToolManager) RemoveTool(name string) error {
+	t.mutex.Lock()
+	index := t.indexOf(name)
+	if index < 0 {
+		t.mutex.Unlock()
+		return fmt.Errorf("failed to remove tool %s: %w", name, ErrToolNotFound)
+	}
+	t.tools = append(t.tools[:index:index], t.tools[index+1:]...)
+	delete(t.toolCallbacks, name)
+	delete(t.validators, name)
+	delete(t.disabled, name)
+	t.mutex.Unlock()
+
+	t.notifyListChanged()
+	return nil
+}
+
+// EnableTool lists a disabled tool again, enabling an enabled tool changes nothing
+func (t *ToolManager) EnableTool(name string) error {
+	return t.setEnabled(name, true)
+}
+
+// DisableTool hides a tool from tools/list and rejects calls to it until it is enabled again
+func (t *ToolManager) DisableTool(name string) error {
+	return t.setEnabled(name, false)
+}
+
+func (t *ToolManager) setEnabled(name string, enabled bool) error {
+	t.mutex.Lock()
+	if t.indexOf(name) < 0 {
+		t.mutex.Unlock()
+		return fmt.Errorf("failed to toggle tool %s: %w", name, ErrToolNotFound)
+	}
+	if t.disabled[name] == !enabled {
+		t.mutex.Unlock()
+		return nil
+	}
+	if enabled {
+		delete(t.disabled, name)
+	} else {
+		t.disabled[name] = true
+	}
+	t.mutex.Unlock()
+
+	t.notifyListChanged()
+	return nil
+}
+
+func (t *ToolManager) IsToolEnabled(name string) bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.indexOf(name) >= 0 && !t.disabled[name]
+}
+
+// OnListChanged registers a listener that runs after every change to the tool list
+func (t *ToolManager) OnListChanged(listener func()) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.listeners = append(t.listeners, listener)
+}
+
+func (t *ToolManager) notifyListChanged() {
+	t.mutex.RLock()
+	listeners := append([]func(){}, t.listeners...)
+	t.mutex.RUnlock()
+
+	for _, listener := range listeners {
+		listener()
+	}
+}
+
+// ValidateArguments checks arguments against the tool's input schema, violations are returned as *SchemaValidationError
+func (t *ToolManager) ValidateArguments(name string, arguments map[string]interface{}) error {
+	t.mutex.RLock()
+	entry, exist := t.validators[name]
+	t.mutex.RUnlock()
+	if !exist {
+		return nil
+	}
+	return entry.validateArguments(name, arguments)
+}
+
+func (entry toolValidator) validateArguments(name string, arguments map[string]interface{}) error {
+	if entry.inputErr != nil {
+		return fmt.Errorf("invalid input schema of tool %s: %w", name, entry.inputErr)
+	}
+	return entry.input.Validate(arguments)
+}
+
+// checkStructuredContent validates structured output and adds its JSON as text for clients that predate structured content
+func (entry toolValidator) checkStructuredContent(name string, result ToolResult) ToolResult {
+	if result.IsError {
+		return result
+	}
+
+	if entry.outputErr != nil {
+		return newToolErrorResult(fmt.Errorf("invalid output schema of tool %s: %w", name, entry.outputErr))
+	}
+	if entry.output != nil {
+		if result.StructuredContent == nil {
+			return newToolErrorResult(fmt.Errorf("tool %s declares an output schema but returned no structured content", name))
+		}
+		if err := entry.output.Validate(result.StructuredContent); err != nil {
+			return newToolErrorResult(fmt.Errorf("tool %s returned invalid structured content: %w", name, err))
+		}
+	}
+	if result.StructuredContent == nil {
+		return result
+	}
+
+	marshaled, err := json.Marshal(result.StructuredContent)
+	if err != nil {
+		return newToolErrorResult(fmt.Errorf("failed to marshal structured content of tool %s: %w", name, err))
+	}
+	text := string(marshaled)
+	for _, content := range result.Content {
+		if content.Type == "text" && content.Text != nil && *content.Text == text {
+			return result
+		}
+	}
+	result.Content = append(result.Content, ToolCallContent{Type: "text", Text: &text})
+	return result
 }
 
 func (t *ToolManager) CallTool(ctx context.Context, name string, arguments map[string]interface{}) ToolResult {
-	toolDef, toolCallback, err := t.findTool(name)
+	entry, err := t.findTool(name)
 	if err != nil {
-		errString := err.Error()
-		return ToolResult{
-			Content: []ToolCallContent{
-				{
-					Type: "text",
-					Text: &errString,
-				},
-			},
-			IsError: true,
+		return newToolErrorResult(err)
+	}
+	return entry.validateAndCall(ctx, arguments)
+}
+
+// validateAndCall reports invalid arguments as a tool error listing every violation, so the model can correct its call
+func (entry toolEntry) validateAndCall(ctx context.Context, arguments map[string]interface{}) ToolResult {
+	if err := entry.validator.validateArguments(entry.tool.Name, arguments); err != nil {
+		result := newToolErrorResult(fmt.Errorf("invalid arguments for tool %s: %w", entry.tool.Name, err))
+		var validationErr *SchemaValidationError
+		if errors.As(err, &validationErr) {
+			result.StructuredContent = validationErr
 		}
+		return result
 	}
 
-	callback := *toolCallback
-	result := callback(ctx, toolDef.Name, arguments)
-	return result
+	return entry.call(ctx, arguments)
+}
+
+// call runs the callback without validating the arguments
+func (entry toolEntry) call(ctx context.Context, arguments map[string]interface{}) ToolResult {
+	result := entry.callback(ctx, entry.tool.Name, arguments)
+	return entry.validator.checkStructuredContent(entry.tool.Name, result)
+}
+
+func newToolErrorResult(err error) ToolResult {
+	text := err.Error()
+	return ToolResult{
+		Content: []ToolCallContent{{Type: "text", Text: &text}},
+		IsError: true,
+	}
 }
 
+// ListAllTools returns a copy of the enabled tools
 func (t *ToolManager) ListAllTools() *[]Tool {
-	return &t.tools
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	tools := make([]Tool, 0, len(t.tools))
+	for _, toolDef := range t.tools {
+		if !t.disabled[toolDef.Name] {
+			tools = append(tools, toolDef)
+		}
+	}
+	return &tools
 }
 
 func NewToolManager() ToolManager {
 	return ToolManager{
+		mutex:         &sync.RWMutex{},
 		tools:         make([]Tool, 0),
 		toolCallbacks: make(ToolCallbacksMap),
+		validators:    make(map[string]toolValidator),
+		disabled:      make(map[string]bool),
 	}
 }
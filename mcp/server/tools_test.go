@@ -0,0 +1,215 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+func TestStructuredContent(t *testing.T) {
+	var structured interface{}
+	summary := "It is sunny"
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "weather",
+		InputSchema: map[string]interface{}{"type": "object"},
+		OutputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"temperature": map[string]interface{}{"type": "number"},
+			},
+			"required": []string{"temperature"},
+		},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		return server.ToolResult{
+			Content:           []server.ToolCallContent{{Type: "text", Text: &summary}},
+			StructuredContent: structured,
+		}
+	})
+
+	structured = map[string]interface{}{"temperature": 27.5}
+	result := toolManager.CallTool(context.Background(), "weather", map[string]interface{}{})
+	if result.IsError || len(result.Content) != 2 || *result.Content[1].Text != `{"temperature":27.5}` {
+		t.Errorf("expected summary and serialized fallback, got %+v", result)
+	}
+
+	structured = map[string]interface{}{"temperature": "warm"}
+	result = toolManager.CallTool(context.Background(), "weather", map[string]interface{}{})
+	if !result.IsError || !strings.Contains(*result.Content[0].Text, "/temperature: expected number") {
+		t.Errorf("expected invalid structured content to fail, got %+v", result)
+	}
+
+	structured = nil
+	result = toolManager.CallTool(context.Background(), "weather", map[string]interface{}{})
+	if !result.IsError || !strings.Contains(*result.Content[0].Text, "returned no structured content") {
+		t.Errorf("expected missing structured content to fail, got %+v", result)
+	}
+}
+
+func textTool(name string, reply string) (server.Tool, server.ToolCallback) {
+	return server.Tool{
+		Name:        name,
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		return server.ToolResult{Content: []server.ToolCallContent{server.TextContent(reply)}}
+	}
+}
+
+func TestDynamicTools(t *testing.T) {
+	changes := 0
+	toolManager := server.NewToolManager()
+	toolManager.OnListChanged(func() { changes++ })
+
+	toolManager.AddTool(textTool("first", "one"))
+	toolManager.AddTool(textTool("second", "two"))
+
+	if err := toolManager.ReplaceTool(textTool("first", "uno")); err != nil {
+		t.Fatalf("failed to replace tool: %v", err)
+	}
+	if result := toolManager.CallTool(context.Background(), "first", map[string]interface{}{}); *result.Content[0].Text != "uno" {
+		t.Errorf("expected replaced callback to run, got %+v", result)
+	}
+
+	if err := toolManager.DisableTool("first"); err != nil {
+		t.Fatalf("failed to disable tool: %v", err)
+	}
+	if err := toolManager.DisableTool("first"); err != nil {
+		t.Fatalf("failed to disable tool twice: %v", err)
+	}
+	if tools := *toolManager.ListAllTools(); len(tools) != 1 || tools[0].Name != "second" {
+		t.Errorf("expected only the enabled tool to be listed, got %+v", tools)
+	}
+	if result := toolManager.CallTool(context.Background(), "first", map[string]interface{}{}); !result.IsError {
+		t.Errorf("expected call to disabled tool to fail, got %+v", result)
+	}
+
+	if err := toolManager.EnableTool("first"); err != nil || !toolManager.IsToolEnabled("first") {
+		t.Fatalf("failed to enable tool: %v", err)
+	}
+	if err := toolManager.RemoveTool("second"); err != nil {
+		t.Fatalf("failed to remove tool: %v", err)
+	}
+	if tools := *toolManager.ListAllTools(); len(tools) != 1 || tools[0].Name != "first" {
+		t.Errorf("expected removed tool to be gone, got %+v", tools)
+	}
+
+	if err := toolManager.RemoveTool("second"); !errors.Is(err, server.ErrToolNotFound) {
+		t.Errorf("expected ErrToolNotFound, got %v", err)
+	}
+	if err := toolManager.ReplaceTool(textTool("third", "three")); !errors.Is(err, server.ErrToolNotFound) {
+		t.Errorf("expected ErrToolNotFound, got %v", err)
+	}
+
+	// add, add, replace, disable, enable and remove, the repeated disable changes nothing
+	if changes != 6 {
+		t.Errorf("expected 6 list changes, got %d", changes)
+	}
+}
+
+// versionedTool returns a tool whose output schema only accepts what its own callback returns
+func versionedTool(version float64) (server.Tool, server.ToolCallback) {
+	definition := server.Tool{
+		Name:        "busy",
+		InputSchema: map[string]interface{}{"type": "object"},
+		OutputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"version": map[string]interface{}{"const": version},
+			},
+			"required": []string{"version"},
+		},
+	}
+	return definition, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		// Give replacements a chance to land while the call is in flight
+		runtime.Gosched()
+		return server.ToolResult{StructuredContent: map[string]interface{}{"version": version}}
+	}
+}
+
+func TestDynamicToolsConcurrentCalls(t *testing.T) {
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(versionedTool(1))
+
+	var wg sync.WaitGroup
+	failures := make(chan server.ToolResult, 400)
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				result := toolManager.CallTool(context.Background(), "busy", map[string]interface{}{})
+				if result.IsError {
+					failures <- result
+				}
+				toolManager.ListAllTools()
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				toolManager.ReplaceTool(versionedTool(float64((i+j)%2 + 1)))
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(failures)
+
+	// A call that validated against one version and ran another would fail the output check
+	for result := range failures {
+		t.Errorf("expected every call to use a single version of the tool, got %s", *result.Content[0].Text)
+	}
+}
+
+func TestToolListChangedNotification(t *testing.T) {
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(textTool("first", "one"))
+
+	transport := startMemoryServer(t, func(s *server.DefaultServer) *server.DefaultServer {
+		s = server.WithToolsCapability(s, true, false)
+		return server.WithToolManager(s, &toolManager)
+	})
+	initializeMemorySession(t, transport, `{}`)
+	transport.send(t, `{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+	transport.receive(t)
+
+	toolManager.AddTool(textTool("second", "two"))
+	notification := transport.receive(t)
+	if notification.Method == nil || *notification.Method != "notifications/tools/list_changed" {
+		t.Fatalf("expected tool list change notification, got %+v", notification)
+	}
+
+	if err := toolManager.DisableTool("first"); err != nil {
+		t.Fatalf("failed to disable tool: %v", err)
+	}
+	if notification := transport.receive(t); notification.Method == nil || *notification.Method != "notifications/tools/list_changed" {
+		t.Fatalf("expected tool list change notification, got %+v", notification)
+	}
+
+	transport.send(t, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	response := transport.receive(t)
+	tools, _ := (*response.Result)["tools"].([]interface{})
+	if len(tools) != 1 || tools[0].(map[string]interface{})["name"] != "second" {
+		t.Errorf("expected only the enabled tool, got %+v", *response.Result)
+	}
+}
+
+func TestToolListChangedNotAdvertised(t *testing.T) {
+	toolManager := server.NewToolManager()
+
+	transport := startMemoryServer(t, func(s *server.DefaultServer) *server.DefaultServer {
+		s = server.WithToolsCapability(s, false, false)
+		return server.WithToolManager(s, &toolManager)
+	})
+	initializeMemorySession(t, transport, `{}`)
+
+	toolManager.AddTool(textTool("first", "one"))
+	transport.send(t, `{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+	if response := transport.receive(t); response.Method != nil {
+		t.Errorf("expected no notification without listChanged, got %+v", response)
+	}
+}
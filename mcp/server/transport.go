@@ -2,10 +2,13 @@ package server
 
 import (
 	"context"
+	"errors"
 
 	"github.com/alwint3r/mcp2go/mcp/messages"
 )
 
+var ErrSessionNotFound = errors.New("session not found")
+
 type Transport interface {
 	Start(ctx context.Context) error
 
@@ -15,3 +18,20 @@ type Transport interface {
 
 	Stop() error
 }
+
+// BatchTransport is implemented by transports that can receive and answer JSON-RPC batches
+type BatchTransport interface {
+	ReadBatch() <-chan messages.JsonRPCBatch
+
+	WriteBatch(batch messages.JsonRPCBatch, ctx context.Context) error
+}
+
+// SessionCloser is implemented by transports that can drop a single client session
+type SessionCloser interface {
+	CloseSession(sessionID string) error
+}
+
+// SessionNotifier is implemented by transports that tell the server when a client session ends
+type SessionNotifier interface {
+	OnSessionClosed(hook func(sessionID string))
+}
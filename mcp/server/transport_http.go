@@ -0,0 +1,348 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+)
+
+const (
+	headerSessionID     = "Mcp-Session-Id"
+	defaultMaxBodyBytes = 4 * 1024 * 1024 // 4MB
+)
+
+var errStreamClosed = errors.New("stream closed")
+
+// httpStream is a queue of outgoing messages drained by a single HTTP handler
+type httpStream struct {
+	messages  chan messages.JsonRPCMessage
+	batches   chan messages.JsonRPCBatch
+	done      chan struct{}
+	closeOnce sync.Once
+	mutex     sync.Mutex
+	finished  bool // The final message is queued, the handler stops reading after it
+}
+
+func newHTTPStream() *httpStream {
+	const streamCapacity = 16
+	return &httpStream{
+		messages: make(chan messages.JsonRPCMessage, streamCapacity),
+		batches:  make(chan messages.JsonRPCBatch, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// send queues a message, it fails once the final message is queued rather than leaving the message unread
+func (s *httpStream) send(msg messages.JsonRPCMessage, ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.finished {
+		return errStreamClosed
+	}
+	return s.enqueue(msg, ctx)
+}
+
+// sendFinal queues the message that ends a request stream, such as the response
+func (s *httpStream) sendFinal(msg messages.JsonRPCMessage, ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.finished {
+		return errStreamClosed
+	}
+	err := s.enqueue(msg, ctx)
+	s.finished = err == nil
+	return err
+}
+
+func (s *httpStream) enqueue(msg messages.JsonRPCMessage, ctx context.Context) error {
+	select {
+	case <-s.done:
+		return errStreamClosed
+	default:
+	}
+
+	select {
+	case s.messages <- msg:
+		return nil
+	case <-s.done:
+		return errStreamClosed
+	case <-ctx.Done():
+		return fmt.Errorf("failed to send message to stream: %w", ctx.Err())
+	}
+}
+
+// sendBatch queues a batch reply on a stream that stays open, such as an SSE session
+func (s *httpStream) sendBatch(batch messages.JsonRPCBatch, ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.finished {
+		return errStreamClosed
+	}
+	return s.enqueueBatch(batch, ctx)
+}
+
+// sendFinalBatch queues the batch reply that ends a request stream
+func (s *httpStream) sendFinalBatch(batch messages.JsonRPCBatch, ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.finished {
+		return errStreamClosed
+	}
+	err := s.enqueueBatch(batch, ctx)
+	s.finished = err == nil
+	return err
+}
+
+func (s *httpStream) enqueueBatch(batch messages.JsonRPCBatch, ctx context.Context) error {
+	select {
+	case <-s.done:
+		return errStreamClosed
+	default:
+	}
+
+	select {
+	case s.batches <- batch:
+		return nil
+	case <-s.done:
+		return errStreamClosed
+	case <-ctx.Done():
+		return fmt.Errorf("failed to send batch to stream: %w", ctx.Err())
+	}
+}
+
+func (s *httpStream) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// messageQueue feeds the transport's read channels from concurrent HTTP handlers
+type messageQueue[T any] struct {
+	channel chan T
+	mutex   sync.RWMutex
+	closed  bool
+}
+
+func newMessageQueue[T any](capacity int) *messageQueue[T] {
+	return &messageQueue[T]{
+		channel: make(chan T, capacity),
+	}
+}
+
+func (q *messageQueue[T]) push(msg T, ctx context.Context, done <-chan struct{}) error {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	if q.closed {
+		return errStreamClosed
+	}
+
+	select {
+	case q.channel <- msg:
+		return nil
+	case <-done:
+		return errStreamClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close must only be called after done is closed so that blocked pushes can return
+func (q *messageQueue[T]) close() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if !q.closed {
+		q.closed = true
+		close(q.channel)
+	}
+}
+
+// sessionClosedHooks keeps the OnSessionClosed hooks of a transport
+type sessionClosedHooks struct {
+	mutex sync.RWMutex
+	hooks []func(sessionID string)
+}
+
+func (h *sessionClosedHooks) add(hook func(sessionID string)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.hooks = append(h.hooks, hook)
+}
+
+func (h *sessionClosedHooks) run(sessionID string) {
+	h.mutex.RLock()
+	hooks := append([]func(string){}, h.hooks...)
+	h.mutex.RUnlock()
+
+	for _, hook := range hooks {
+		hook(sessionID)
+	}
+}
+
+func newSessionID() (string, error) {
+	buffer := make([]byte, 16)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return hex.EncodeToString(buffer), nil
+}
+
+func acceptsEventStream(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		if strings.Contains(accept, "text/event-stream") {
+			return true
+		}
+	}
+	return false
+}
+
+// isOriginAllowed guards against DNS rebinding, an empty list allows only loopback origins and "*" allows every origin
+func isOriginAllowed(r *http.Request, allowedOrigins []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if len(allowedOrigins) == 0 {
+		return isLoopbackOrigin(origin)
+	}
+
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func isLoopbackOrigin(origin string) bool {
+	parsed, err := url.Parse(origin)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+
+	host := parsed.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func startEventStream(w http.ResponseWriter) (http.Flusher, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errors.New("response writer does not support streaming")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return flusher, nil
+}
+
+func writeSSEEvent(w http.ResponseWriter, flusher http.Flusher, event string, data []byte) error {
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	flusher.Flush()
+	return nil
+}
+
+func writeJSONMessage(w http.ResponseWriter, status int, msg interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(msg)
+}
+
+func writeJSONRPCError(w http.ResponseWriter, status int, code int64, message string) {
+	errorMsg := messages.NewJsonRPCMessage()
+	errorMsg.Error = &messages.ErrorResponse{
+		Code:    code,
+		Message: message,
+	}
+	writeJSONMessage(w, status, errorMsg)
+}
+
+func serveEventStream(w http.ResponseWriter, r *http.Request, stream *httpStream, done <-chan struct{}, logger *Logger, untilResponse bool) {
+	flusher, err := startEventStream(w)
+	if err != nil {
+		logger.Error("Failed to start event stream: %v", err)
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	streamMessages(w, flusher, r, stream, done, logger, untilResponse)
+}
+
+func streamMessages(w http.ResponseWriter, flusher http.Flusher, r *http.Request, stream *httpStream, done <-chan struct{}, logger *Logger, untilResponse bool) {
+	for {
+		select {
+		case msg := <-stream.messages:
+			data, err := json.Marshal(msg)
+			if err != nil {
+				logger.Error("Failed to marshal message: %v", err)
+				continue
+			}
+			if err := writeSSEEvent(w, flusher, "message", data); err != nil {
+				logger.Error("Failed to write to event stream: %v", err)
+				return
+			}
+			if untilResponse && msg.IsResponse() {
+				return
+			}
+		case batch := <-stream.batches:
+			data, err := json.Marshal(batch)
+			if err != nil {
+				logger.Error("Failed to marshal batch: %v", err)
+				continue
+			}
+			if err := writeSSEEvent(w, flusher, "message", data); err != nil {
+				logger.Error("Failed to write to event stream: %v", err)
+				return
+			}
+			if untilResponse {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-stream.done:
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+// decodeBatchBody answers malformed and empty batches directly, it returns false when it did
+func decodeBatchBody(w http.ResponseWriter, body []byte, logger *Logger) (messages.JsonRPCBatch, bool) {
+	batch, err := messages.DecodeBatch(body)
+	if errors.Is(err, messages.ErrEmptyBatch) {
+		logger.Error("Received empty batch")
+		writeJSONRPCError(w, http.StatusBadRequest, messages.JsonRPCErrorInvalidRequest, "Invalid Request: empty batch")
+		return batch, false
+	} else if err != nil {
+		logger.Error("Error parsing JSON: %v", err)
+		writeJSONRPCError(w, http.StatusBadRequest, messages.JsonRPCErrorParse, fmt.Sprintf("Failed to parse JSON: %v", err))
+		return batch, false
+	}
+	return batch, true
+}
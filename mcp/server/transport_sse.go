@@ -0,0 +1,293 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+)
+
+// SSEConfig configures the HTTP+SSE transport from protocol revision 2024-11-05
+type SSEConfig struct {
+	// MessageEndpoint is the path announced to clients in the endpoint event
+	MessageEndpoint string
+	// AllowedOrigins restricts the Origin header of incoming requests, an empty list allows only loopback origins and "*" allows every origin
+	AllowedOrigins []string
+	MaxBodyBytes   int64
+}
+
+func NewDefaultSSEConfig() SSEConfig {
+	return SSEConfig{
+		MessageEndpoint: "/messages",
+		AllowedOrigins:  []string{},
+		MaxBodyBytes:    defaultMaxBodyBytes,
+	}
+}
+
+type SSETransport struct {
+	reader        *messageQueue[messages.JsonRPCMessage]
+	batchReader   *messageQueue[messages.JsonRPCBatch]
+	logger        *Logger
+	config        SSEConfig
+	sessions      map[string]*httpStream
+	sessionsMutex sync.RWMutex
+	closedHooks   sessionClosedHooks
+	done          chan struct{}
+	stopOnce      sync.Once
+}
+
+func NewSSETransport(messageEndpoint string) *SSETransport {
+	config := NewDefaultSSEConfig()
+	config.MessageEndpoint = messageEndpoint
+	return NewSSETransportWithConfig(config)
+}
+
+func NewSSETransportWithConfig(config SSEConfig) *SSETransport {
+	const channelCapacity = 100
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = defaultMaxBodyBytes
+	}
+
+	logger := NewLogger("SSETransport")
+	logger.Out = os.Stderr
+	logger.ErrOut = os.Stderr
+	return &SSETransport{
+		reader:      newMessageQueue[messages.JsonRPCMessage](channelCapacity),
+		batchReader: newMessageQueue[messages.JsonRPCBatch](channelCapacity),
+		logger:      logger,
+		config:      config,
+		sessions:    make(map[string]*httpStream),
+		done:        make(chan struct{}),
+	}
+}
+
+func (t *SSETransport) Read() <-chan messages.JsonRPCMessage {
+	return t.reader.channel
+}
+
+func (t *SSETransport) Write(msg messages.JsonRPCMessage, ctx context.Context) error {
+	stream := t.findSession(msg.SessionID)
+	if stream == nil {
+		return fmt.Errorf("failed to write message to session %q: %w", msg.SessionID, ErrSessionNotFound)
+	}
+
+	if msg.IsResponse() {
+		if msg.Error != nil {
+			t.logger.Debug("Sent error response: id=%v, code=%d, session=%s", msg.ID, msg.Error.Code, msg.SessionID)
+		} else {
+			t.logger.Debug("Sent success response: id=%v, session=%s", msg.ID, msg.SessionID)
+		}
+	} else if msg.IsRequest() {
+		t.logger.Debug("Sent request: method=%s, id=%v, session=%s", *msg.Method, msg.ID, msg.SessionID)
+	} else if msg.IsNotification() {
+		t.logger.Debug("Sent notification: method=%s, session=%s", *msg.Method, msg.SessionID)
+	}
+
+	return stream.send(msg, ctx)
+}
+
+func (t *SSETransport) ReadBatch() <-chan messages.JsonRPCBatch {
+	return t.batchReader.channel
+}
+
+func (t *SSETransport) WriteBatch(batch messages.JsonRPCBatch, ctx context.Context) error {
+	stream := t.findSession(batch.SessionID)
+	if stream == nil {
+		return fmt.Errorf("failed to write batch to session %q: %w", batch.SessionID, ErrSessionNotFound)
+	}
+
+	t.logger.Debug("Sent batch response: %d messages, session=%s", len(batch.Messages), batch.SessionID)
+	return stream.sendBatch(batch, ctx)
+}
+
+func (t *SSETransport) Start(ctx context.Context) error {
+	t.logger.Info("Starting SSE transport")
+	defer t.logger.Info("SSE transport stopped")
+
+	select {
+	case <-ctx.Done():
+		t.logger.Info("Context cancelled, stopping transport: %v", ctx.Err())
+		t.Stop()
+		return fmt.Errorf("transport stopped: %w", ctx.Err())
+	case <-t.done:
+		return nil
+	}
+}
+
+func (t *SSETransport) Stop() error {
+	t.stopOnce.Do(func() {
+		t.logger.Info("Closing transport")
+		close(t.done)
+
+		t.sessionsMutex.Lock()
+		for id, stream := range t.sessions {
+			stream.close()
+			delete(t.sessions, id)
+		}
+		t.sessionsMutex.Unlock()
+
+		t.reader.close()
+		t.batchReader.close()
+		t.logger.Info("Transport closed")
+	})
+	return nil
+}
+
+func (t *SSETransport) CloseSession(sessionID string) error {
+	t.sessionsMutex.Lock()
+	stream, exist := t.sessions[sessionID]
+	delete(t.sessions, sessionID)
+	t.sessionsMutex.Unlock()
+
+	if !exist {
+		return ErrSessionNotFound
+	}
+
+	stream.close()
+	t.logger.Info("Session closed: %s", sessionID)
+	t.closedHooks.run(sessionID)
+	return nil
+}
+
+// OnSessionClosed registers a hook that runs after a session is closed by the server or its event stream disconnects
+func (t *SSETransport) OnSessionClosed(hook func(sessionID string)) {
+	t.closedHooks.add(hook)
+}
+
+// ServeHTTP serves both endpoints, GET opens the event stream and POST delivers client messages
+func (t *SSETransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !isOriginAllowed(r, t.config.AllowedOrigins) {
+		t.logger.Warn("Rejected request from origin %s", r.Header.Get("Origin"))
+		http.Error(w, "Forbidden origin", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		t.handleEventStream(w, r)
+	case http.MethodPost:
+		t.handleMessage(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (t *SSETransport) findSession(sessionID string) *httpStream {
+	t.sessionsMutex.RLock()
+	defer t.sessionsMutex.RUnlock()
+
+	return t.sessions[sessionID]
+}
+
+func (t *SSETransport) endpointFor(sessionID string) string {
+	separator := "?"
+	if strings.Contains(t.config.MessageEndpoint, "?") {
+		separator = "&"
+	}
+	return t.config.MessageEndpoint + separator + "sessionId=" + sessionID
+}
+
+func (t *SSETransport) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := newSessionID()
+	if err != nil {
+		t.logger.Error("Failed to create session: %v", err)
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+
+	flusher, err := startEventStream(w)
+	if err != nil {
+		t.logger.Error("Failed to start event stream: %v", err)
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	stream := newHTTPStream()
+	t.sessionsMutex.Lock()
+	t.sessions[sessionID] = stream
+	t.sessionsMutex.Unlock()
+	t.logger.Info("Session created: %s", sessionID)
+
+	defer func() {
+		t.sessionsMutex.Lock()
+		owned := t.sessions[sessionID] == stream
+		if owned {
+			delete(t.sessions, sessionID)
+		}
+		t.sessionsMutex.Unlock()
+		stream.close()
+		t.logger.Info("Event stream closed: %s", sessionID)
+		// CloseSession already ran the hooks when it removed the session
+		if owned {
+			t.closedHooks.run(sessionID)
+		}
+	}()
+
+	if err := writeSSEEvent(w, flusher, "endpoint", []byte(t.endpointFor(sessionID))); err != nil {
+		t.logger.Error("Failed to send endpoint event: %v", err)
+		return
+	}
+
+	streamMessages(w, flusher, r, stream, t.done, t.logger, false)
+}
+
+func (t *SSETransport) handleMessage(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.URL.Query().Get("sessionId")
+	if sessionID == "" {
+		http.Error(w, "Missing sessionId parameter", http.StatusBadRequest)
+		return
+	}
+	if t.findSession(sessionID) == nil {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, t.config.MaxBodyBytes))
+	if err != nil {
+		t.logger.Error("Failed to read request body: %v", err)
+		writeJSONRPCError(w, http.StatusBadRequest, messages.JsonRPCErrorParse, fmt.Sprintf("Failed to read body: %v", err))
+		return
+	}
+
+	if messages.IsBatch(body) {
+		batch, ok := decodeBatchBody(w, body, t.logger)
+		if !ok {
+			return
+		}
+		batch.SessionID = sessionID
+		for i := range batch.Messages {
+			batch.Messages[i].SessionID = sessionID
+		}
+
+		if err := t.batchReader.push(batch, r.Context(), t.done); err != nil {
+			t.logger.Error("Failed to queue batch: %v", err)
+			http.Error(w, "Transport unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	var msg messages.JsonRPCMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		t.logger.Error("Error parsing JSON: %v", err)
+		writeJSONRPCError(w, http.StatusBadRequest, messages.JsonRPCErrorParse, fmt.Sprintf("Failed to parse JSON: %v", err))
+		return
+	}
+	msg.SessionID = sessionID
+
+	if err := t.reader.push(msg, r.Context(), t.done); err != nil {
+		t.logger.Error("Failed to queue message: %v", err)
+		http.Error(w, "Transport unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
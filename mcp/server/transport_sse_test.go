@@ -0,0 +1,170 @@
+package server_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+func TestSSETransportRoundTrip(t *testing.T) {
+	transport := server.NewSSETransport("/messages")
+	s := startTestServer(t, transport)
+
+	mux := http.NewServeMux()
+	mux.Handle("/sse", transport)
+	mux.Handle("/messages", transport)
+	httpServer := httptest.NewServer(mux)
+	defer httpServer.Close()
+
+	streamResp, err := http.Get(httpServer.URL + "/sse")
+	if err != nil {
+		t.Fatalf("failed to open event stream: %v", err)
+	}
+	defer streamResp.Body.Close()
+
+	reader := bufio.NewReader(streamResp.Body)
+	var endpoint string
+	for endpoint == "" {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read endpoint event: %v", err)
+		}
+		if data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: "); ok {
+			endpoint = data
+		}
+	}
+	if !strings.HasPrefix(endpoint, "/messages?sessionId=") {
+		t.Fatalf("unexpected endpoint: %s", endpoint)
+	}
+
+	resp := postMessage(t, httpServer.URL+endpoint, "", "application/json", initializeBody)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("message should be accepted, got status %d", resp.StatusCode)
+	}
+
+	msg := readEventStreamMessage(t, reader)
+	if !msg.IsResponse() || msg.ID != float64(1) {
+		t.Errorf("expected initialize response on the event stream, got %+v", msg)
+	}
+
+	resp = postMessage(t, httpServer.URL+"/messages?sessionId=unknown", "", "application/json", initializeBody)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown session should be rejected with 404, got %d", resp.StatusCode)
+	}
+
+	resp = postMessage(t, httpServer.URL+"/messages", "", "application/json", initializeBody)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("missing session should be rejected with 400, got %d", resp.StatusCode)
+	}
+
+	if sessions := s.Sessions(); len(sessions) != 1 {
+		t.Fatalf("expected the server to hold the session, got %+v", sessions)
+	}
+	streamResp.Body.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(s.Sessions()) != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sessions := s.Sessions(); len(sessions) != 0 {
+		t.Errorf("expected disconnected session to be removed from the server, got %+v", sessions)
+	}
+}
+
+func TestSSEDefaultOrigins(t *testing.T) {
+	transport := server.NewSSETransport("/messages")
+	startTestServer(t, transport)
+
+	mux := http.NewServeMux()
+	mux.Handle("/sse", transport)
+	httpServer := httptest.NewServer(mux)
+	defer httpServer.Close()
+
+	openStream := func(origin string) int {
+		req, _ := http.NewRequest(http.MethodGet, httpServer.URL+"/sse", nil)
+		req.Header.Set("Origin", origin)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to open event stream: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := openStream("http://evil.example"); status != http.StatusForbidden {
+		t.Errorf("foreign origin should be rejected with 403 by default, got %d", status)
+	}
+	if status := openStream("http://localhost:3000"); status != http.StatusOK {
+		t.Errorf("loopback origin should be allowed by default, got %d", status)
+	}
+}
+
+func TestSSEBatchThenRequest(t *testing.T) {
+	transport := server.NewSSETransport("/messages")
+	startTestServer(t, transport)
+
+	mux := http.NewServeMux()
+	mux.Handle("/sse", transport)
+	mux.Handle("/messages", transport)
+	httpServer := httptest.NewServer(mux)
+	defer httpServer.Close()
+
+	streamResp, err := http.Get(httpServer.URL + "/sse")
+	if err != nil {
+		t.Fatalf("failed to open event stream: %v", err)
+	}
+	defer streamResp.Body.Close()
+
+	reader := bufio.NewReader(streamResp.Body)
+	endpoint := readEventStreamData(t, reader)
+
+	post := func(body string) {
+		resp := postMessage(t, httpServer.URL+endpoint, "", "application/json", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("message should be accepted, got status %d", resp.StatusCode)
+		}
+	}
+
+	post(initializeBody)
+	readEventStreamMessage(t, reader)
+	post(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	post(`[{"jsonrpc":"2.0","id":5,"method":"ping"}]`)
+	var batch []messages.JsonRPCMessage
+	if err := json.Unmarshal([]byte(readEventStreamData(t, reader)), &batch); err != nil {
+		t.Fatalf("failed to decode batch reply: %v", err)
+	}
+	if len(batch) != 1 || batch[0].ID != float64(5) {
+		t.Fatalf("unexpected batch reply: %+v", batch)
+	}
+
+	post(`{"jsonrpc":"2.0","id":6,"method":"ping"}`)
+	msg := readEventStreamMessage(t, reader)
+	if !msg.IsResponse() || msg.ID != float64(6) {
+		t.Errorf("expected ping response after the batch, got %+v", msg)
+	}
+}
+
+func readEventStreamData(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read event stream: %v", err)
+		}
+		if data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: "); ok {
+			return data
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -12,26 +13,32 @@ import (
 )
 
 type StdioTransport struct {
-	readerChannel chan messages.JsonRPCMessage
-	logger        *Logger
-	writerChannel chan messages.JsonRPCMessage
+	readerChannel      chan messages.JsonRPCMessage
+	logger             *Logger
+	writerChannel      chan messages.JsonRPCMessage
+	batchReaderChannel chan messages.JsonRPCBatch
+	batchWriterChannel chan messages.JsonRPCBatch
 }
 
 func NewStdioTransport() *StdioTransport {
 	const channelCapacity = 100
 	readerChannel := make(chan messages.JsonRPCMessage, channelCapacity)
 	writerChannel := make(chan messages.JsonRPCMessage, channelCapacity)
+	batchReaderChannel := make(chan messages.JsonRPCBatch, channelCapacity)
+	batchWriterChannel := make(chan messages.JsonRPCBatch, channelCapacity)
 	logger := NewLogger("StdioTransport")
 	logger.Out = os.Stderr
 	logger.ErrOut = os.Stderr
 	return &StdioTransport{
-		readerChannel: readerChannel,
-		logger:        logger,
-		writerChannel: writerChannel,
+		readerChannel:      readerChannel,
+		logger:             logger,
+		writerChannel:      writerChannel,
+		batchReaderChannel: batchReaderChannel,
+		batchWriterChannel: batchWriterChannel,
 	}
 }
 
-func (s *StdioTransport) write(msg messages.JsonRPCMessage) error {
+func (s *StdioTransport) write(msg interface{}) error {
 	marshaled, err := json.Marshal(msg)
 	if err != nil {
 		s.logger.Error("Failed to marshal message: %v", err)
@@ -82,6 +89,38 @@ func (s *StdioTransport) Write(msg messages.JsonRPCMessage, ctx context.Context)
 	}
 }
 
+func (s *StdioTransport) ReadBatch() <-chan messages.JsonRPCBatch {
+	return s.batchReaderChannel
+}
+
+func (s *StdioTransport) WriteBatch(batch messages.JsonRPCBatch, ctx context.Context) error {
+	s.logger.Debug("Sent batch response: %d messages", len(batch.Messages))
+
+	select {
+	case s.batchWriterChannel <- batch:
+		return nil
+	case <-ctx.Done():
+		s.logger.Warn("Batch writer channel full, falling back to direct write: %v", ctx.Err())
+		return s.write(batch)
+	}
+}
+
+func (s *StdioTransport) writeError(ctx context.Context, code int64, message string) {
+	errorMsg := messages.NewJsonRPCMessage()
+	errorMsg.Error = &messages.ErrorResponse{
+		Code:    code,
+		Message: message,
+	}
+
+	go func() {
+		withTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if writeErr := s.Write(*errorMsg, withTimeout); writeErr != nil {
+			s.logger.Error("Failed to write error response: %v", writeErr)
+		}
+	}()
+}
+
 func (s *StdioTransport) Stop() error {
 	s.logger.Info("Closing transport")
 
@@ -170,23 +209,34 @@ func (s *StdioTransport) Start(ctx context.Context) error {
 
 			s.logger.Debug("Received input line: %d bytes", len(line))
 
+			if messages.IsBatch([]byte(line)) {
+				batch, err := messages.DecodeBatch([]byte(line))
+				if errors.Is(err, messages.ErrEmptyBatch) {
+					s.logger.Error("Received empty batch")
+					s.writeError(ctx, messages.JsonRPCErrorInvalidRequest, "Invalid Request: empty batch")
+					continue
+				} else if err != nil {
+					s.logger.Error("Error parsing JSON: %v", err)
+					s.writeError(ctx, messages.JsonRPCErrorParse, fmt.Sprintf("Failed to parse JSON: %v", err))
+					continue
+				}
+
+				select {
+				case s.batchReaderChannel <- batch:
+					s.logger.Debug("Queued batch: %d messages", len(batch.Messages))
+				case <-ctx.Done():
+					s.logger.Warn("Context cancelled while sending batch")
+					cancelScanner()
+					return fmt.Errorf("transport stopped while sending batch: %w", ctx.Err())
+				}
+				continue
+			}
+
 			var msg messages.JsonRPCMessage
 			err := json.Unmarshal([]byte(line), &msg)
 			if err != nil {
 				s.logger.Error("Error parsing JSON: %v", err)
-				errorMsg := messages.NewJsonRPCMessage()
-				errorMsg.Error = &messages.ErrorResponse{
-					Code:    messages.JsonRPCErrorParse,
-					Message: fmt.Sprintf("Failed to parse JSON: %v", err),
-				}
-
-				go func() {
-					withTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
-					defer cancel()
-					if writeErr := s.Write(*errorMsg, withTimeout); writeErr != nil {
-						s.logger.Error("Failed to write error response: %v", writeErr)
-					}
-				}()
+				s.writeError(ctx, messages.JsonRPCErrorParse, fmt.Sprintf("Failed to parse JSON: %v", err))
 				continue
 			}
 
@@ -210,6 +260,12 @@ func (s *StdioTransport) Start(ctx context.Context) error {
 			if err != nil {
 				s.logger.Error("Failed to write response: %v", err)
 			}
+
+		case outgoing := <-s.batchWriterChannel:
+			err := s.write(outgoing)
+			if err != nil {
+				s.logger.Error("Failed to write batch response: %v", err)
+			}
 		}
 	}
 }
@@ -0,0 +1,612 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+)
+
+type StreamableHTTPConfig struct {
+	// AllowedOrigins restricts the Origin header of incoming requests, an empty list allows only loopback origins and "*" allows every origin
+	AllowedOrigins []string
+	// JSONResponse makes POST requests answered with a single JSON body instead of an SSE stream
+	JSONResponse bool
+	MaxBodyBytes int64
+	// SessionIdleTimeoutSeconds closes sessions without open streams after this long without a request, 0 uses the default timeout
+	SessionIdleTimeoutSeconds time.Duration
+}
+
+func NewDefaultStreamableHTTPConfig() StreamableHTTPConfig {
+	return StreamableHTTPConfig{
+		AllowedOrigins:            []string{},
+		JSONResponse:              false,
+		MaxBodyBytes:              defaultMaxBodyBytes,
+		SessionIdleTimeoutSeconds: defaultSessionIdleTimeoutSeconds,
+	}
+}
+
+const defaultSessionIdleTimeoutSeconds = 30 * 60
+
+type streamableHTTPSession struct {
+	id               string
+	mutex            sync.Mutex
+	requestStreams   map[interface{}]*httpStream
+	eventStreams     map[interface{}]bool
+	standaloneStream *httpStream
+	initializeID     interface{}
+	initialized      bool
+	initializeFailed bool
+	lastActive       time.Time
+}
+
+func (s *streamableHTTPSession) touch() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastActive = time.Now()
+}
+
+// idleSince reports when the session went idle, sessions with an open stream are never idle
+func (s *streamableHTTPSession) idleSince() (time.Time, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.standaloneStream != nil || len(s.requestStreams) > 0 {
+		return time.Time{}, false
+	}
+	return s.lastActive, true
+}
+
+// recordResponse notes whether the response answers the initialize request that created the session
+func (s *streamableHTTPSession) recordResponse(msg messages.JsonRPCMessage) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.initialized || s.initializeFailed || msg.ID != s.initializeID {
+		return
+	}
+	if msg.Error != nil {
+		s.initializeFailed = true
+	} else {
+		s.initialized = true
+	}
+}
+
+func (s *streamableHTTPSession) failedToInitialize() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.initializeFailed
+}
+
+// openRequestStream refuses an ID that is still in flight, its stream would otherwise take the first request's response
+func (s *streamableHTTPSession) openRequestStream(requestID interface{}, isEventStream bool) (*httpStream, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exist := s.requestStreams[requestID]; exist {
+		return nil, false
+	}
+	stream := newHTTPStream()
+	s.requestStreams[requestID] = stream
+	s.eventStreams[requestID] = isEventStream
+	return stream, true
+}
+
+func (s *streamableHTTPSession) closeRequestStream(requestID interface{}, stream *httpStream) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stream.close()
+	if s.requestStreams[requestID] == stream {
+		delete(s.requestStreams, requestID)
+		delete(s.eventStreams, requestID)
+	}
+	s.lastActive = time.Now()
+}
+
+func (s *streamableHTTPSession) takeRequestStream(requestID interface{}) *httpStream {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stream, exist := s.requestStreams[requestID]
+	if !exist {
+		return nil
+	}
+	delete(s.requestStreams, requestID)
+	delete(s.eventStreams, requestID)
+	return stream
+}
+
+// outgoingStream picks the SSE stream of the related request, unrelated messages only go to the standalone GET stream
+func (s *streamableHTTPSession) outgoingStream(relatedRequestID interface{}) *httpStream {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if relatedRequestID != nil && s.eventStreams[relatedRequestID] {
+		return s.requestStreams[relatedRequestID]
+	}
+	return s.standaloneStream
+}
+
+func (s *streamableHTTPSession) close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for requestID, stream := range s.requestStreams {
+		stream.close()
+		delete(s.requestStreams, requestID)
+		delete(s.eventStreams, requestID)
+	}
+	if s.standaloneStream != nil {
+		s.standaloneStream.close()
+		s.standaloneStream = nil
+	}
+}
+
+// batchTag keys pending batch replies apart from client request IDs
+type batchTag uint64
+
+type StreamableHTTPTransport struct {
+	reader        *messageQueue[messages.JsonRPCMessage]
+	batchReader   *messageQueue[messages.JsonRPCBatch]
+	nextBatchTag  atomic.Uint64
+	logger        *Logger
+	config        StreamableHTTPConfig
+	sessions      map[string]*streamableHTTPSession
+	sessionsMutex sync.RWMutex
+	closedHooks   sessionClosedHooks
+	done          chan struct{}
+	stopOnce      sync.Once
+}
+
+func NewStreamableHTTPTransport() *StreamableHTTPTransport {
+	return NewStreamableHTTPTransportWithConfig(NewDefaultStreamableHTTPConfig())
+}
+
+func NewStreamableHTTPTransportWithConfig(config StreamableHTTPConfig) *StreamableHTTPTransport {
+	const channelCapacity = 100
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	if config.SessionIdleTimeoutSeconds <= 0 {
+		config.SessionIdleTimeoutSeconds = defaultSessionIdleTimeoutSeconds
+	}
+
+	logger := NewLogger("StreamableHTTPTransport")
+	logger.Out = os.Stderr
+	logger.ErrOut = os.Stderr
+	return &StreamableHTTPTransport{
+		reader:      newMessageQueue[messages.JsonRPCMessage](channelCapacity),
+		batchReader: newMessageQueue[messages.JsonRPCBatch](channelCapacity),
+		logger:      logger,
+		config:      config,
+		sessions:    make(map[string]*streamableHTTPSession),
+		done:        make(chan struct{}),
+	}
+}
+
+func (t *StreamableHTTPTransport) Read() <-chan messages.JsonRPCMessage {
+	return t.reader.channel
+}
+
+func (t *StreamableHTTPTransport) Write(msg messages.JsonRPCMessage, ctx context.Context) error {
+	session := t.findSession(msg.SessionID)
+	if session == nil {
+		return fmt.Errorf("failed to write message to session %q: %w", msg.SessionID, ErrSessionNotFound)
+	}
+
+	if msg.IsResponse() {
+		stream := session.takeRequestStream(msg.ID)
+		if stream == nil {
+			return fmt.Errorf("no pending request with id %v in session %q", msg.ID, msg.SessionID)
+		}
+		session.recordResponse(msg)
+		if msg.Error != nil {
+			t.logger.Debug("Sent error response: id=%v, code=%d, session=%s", msg.ID, msg.Error.Code, msg.SessionID)
+		} else {
+			t.logger.Debug("Sent success response: id=%v, session=%s", msg.ID, msg.SessionID)
+		}
+		return stream.sendFinal(msg, ctx)
+	}
+
+	stream := session.outgoingStream(msg.RelatedRequestID)
+	if stream == nil {
+		return fmt.Errorf("no open stream in session %q", msg.SessionID)
+	}
+	if msg.IsRequest() {
+		t.logger.Debug("Sent request: method=%s, id=%v, session=%s", *msg.Method, msg.ID, msg.SessionID)
+	} else if msg.IsNotification() {
+		t.logger.Debug("Sent notification: method=%s, session=%s", *msg.Method, msg.SessionID)
+	}
+	err := stream.send(msg, ctx)
+	if errors.Is(err, errStreamClosed) && msg.RelatedRequestID != nil {
+		// The request was answered meanwhile, its stream takes no more messages
+		if standalone := session.outgoingStream(nil); standalone != nil && standalone != stream {
+			return standalone.send(msg, ctx)
+		}
+	}
+	return err
+}
+
+func (t *StreamableHTTPTransport) ReadBatch() <-chan messages.JsonRPCBatch {
+	return t.batchReader.channel
+}
+
+func (t *StreamableHTTPTransport) WriteBatch(batch messages.JsonRPCBatch, ctx context.Context) error {
+	session := t.findSession(batch.SessionID)
+	if session == nil {
+		return fmt.Errorf("failed to write batch to session %q: %w", batch.SessionID, ErrSessionNotFound)
+	}
+
+	stream := session.takeRequestStream(batch.Tag)
+	if stream == nil {
+		return fmt.Errorf("no pending batch in session %q", batch.SessionID)
+	}
+
+	t.logger.Debug("Sent batch response: %d messages, session=%s", len(batch.Messages), batch.SessionID)
+	return stream.sendFinalBatch(batch, ctx)
+}
+
+func (t *StreamableHTTPTransport) Start(ctx context.Context) error {
+	t.logger.Info("Starting Streamable HTTP transport")
+	defer t.logger.Info("Streamable HTTP transport stopped")
+
+	idleTimeout := t.config.SessionIdleTimeoutSeconds * time.Second
+	ticker := time.NewTicker(idleTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.logger.Info("Context cancelled, stopping transport: %v", ctx.Err())
+			t.Stop()
+			return fmt.Errorf("transport stopped: %w", ctx.Err())
+		case <-t.done:
+			return nil
+		case <-ticker.C:
+			t.closeIdleSessions(idleTimeout)
+		}
+	}
+}
+
+// closeIdleSessions closes sessions that had no open stream and no request for longer than idleTimeout
+func (t *StreamableHTTPTransport) closeIdleSessions(idleTimeout time.Duration) {
+	t.sessionsMutex.RLock()
+	var idle []string
+	for id, session := range t.sessions {
+		if since, ok := session.idleSince(); ok && time.Since(since) > idleTimeout {
+			idle = append(idle, id)
+		}
+	}
+	t.sessionsMutex.RUnlock()
+
+	for _, id := range idle {
+		t.logger.Info("Closing idle session: %s", id)
+		t.CloseSession(id)
+	}
+}
+
+func (t *StreamableHTTPTransport) Stop() error {
+	t.stopOnce.Do(func() {
+		t.logger.Info("Closing transport")
+		close(t.done)
+
+		t.sessionsMutex.Lock()
+		for id, session := range t.sessions {
+			session.close()
+			delete(t.sessions, id)
+		}
+		t.sessionsMutex.Unlock()
+
+		t.reader.close()
+		t.batchReader.close()
+
+		t.logger.Info("Transport closed")
+	})
+	return nil
+}
+
+func (t *StreamableHTTPTransport) CloseSession(sessionID string) error {
+	t.sessionsMutex.Lock()
+	session, exist := t.sessions[sessionID]
+	delete(t.sessions, sessionID)
+	t.sessionsMutex.Unlock()
+
+	if !exist {
+		return ErrSessionNotFound
+	}
+
+	session.close()
+	t.logger.Info("Session closed: %s", sessionID)
+	t.closedHooks.run(sessionID)
+	return nil
+}
+
+// OnSessionClosed registers a hook that runs after a session is deleted by the client or closed by the server
+func (t *StreamableHTTPTransport) OnSessionClosed(hook func(sessionID string)) {
+	t.closedHooks.add(hook)
+}
+
+func (t *StreamableHTTPTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !isOriginAllowed(r, t.config.AllowedOrigins) {
+		t.logger.Warn("Rejected request from origin %s", r.Header.Get("Origin"))
+		http.Error(w, "Forbidden origin", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		t.handlePost(w, r)
+	case http.MethodGet:
+		t.handleGet(w, r)
+	case http.MethodDelete:
+		t.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (t *StreamableHTTPTransport) findSession(sessionID string) *streamableHTTPSession {
+	t.sessionsMutex.RLock()
+	defer t.sessionsMutex.RUnlock()
+
+	return t.sessions[sessionID]
+}
+
+func (t *StreamableHTTPTransport) createSession(initializeID interface{}) (*streamableHTTPSession, error) {
+	sessionID, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &streamableHTTPSession{
+		id:             sessionID,
+		requestStreams: make(map[interface{}]*httpStream),
+		eventStreams:   make(map[interface{}]bool),
+		initializeID:   initializeID,
+		lastActive:     time.Now(),
+	}
+
+	t.sessionsMutex.Lock()
+	t.sessions[sessionID] = session
+	t.sessionsMutex.Unlock()
+
+	t.logger.Info("Session created: %s", sessionID)
+	return session, nil
+}
+
+func (t *StreamableHTTPTransport) sessionFromRequest(w http.ResponseWriter, r *http.Request) *streamableHTTPSession {
+	sessionID := r.Header.Get(headerSessionID)
+	if sessionID == "" {
+		http.Error(w, "Missing "+headerSessionID+" header", http.StatusBadRequest)
+		return nil
+	}
+
+	session := t.findSession(sessionID)
+	if session == nil {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return nil
+	}
+
+	session.touch()
+	return session
+}
+
+func (t *StreamableHTTPTransport) handlePost(w http.ResponseWriter, r *http.Request) {
+	if contentType := r.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, t.config.MaxBodyBytes))
+	if err != nil {
+		t.logger.Error("Failed to read request body: %v", err)
+		writeJSONRPCError(w, http.StatusBadRequest, messages.JsonRPCErrorParse, fmt.Sprintf("Failed to read body: %v", err))
+		return
+	}
+
+	if messages.IsBatch(body) {
+		t.handlePostBatch(w, r, body)
+		return
+	}
+
+	var msg messages.JsonRPCMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		t.logger.Error("Error parsing JSON: %v", err)
+		writeJSONRPCError(w, http.StatusBadRequest, messages.JsonRPCErrorParse, fmt.Sprintf("Failed to parse JSON: %v", err))
+		return
+	}
+	// Request streams are keyed by ID, so objects and arrays are refused before they reach a map
+	if !messages.IsValidID(msg.ID) {
+		writeJSONRPCError(w, http.StatusBadRequest, messages.JsonRPCErrorInvalidRequest, "Invalid Request: id must be a string or number")
+		return
+	}
+
+	var session *streamableHTTPSession
+	if msg.IsRequest() && *msg.Method == "initialize" {
+		session, err = t.createSession(msg.ID)
+		if err != nil {
+			t.logger.Error("Failed to create session: %v", err)
+			writeJSONRPCError(w, http.StatusInternalServerError, messages.JsonRPCErrorInternalError, "Failed to create session")
+			return
+		}
+		w.Header().Set(headerSessionID, session.id)
+		// A rejected initialize leaves nothing for the client to resume, so its session goes away with the response
+		defer func() {
+			if session.failedToInitialize() {
+				t.CloseSession(session.id)
+			}
+		}()
+	} else {
+		session = t.sessionFromRequest(w, r)
+		if session == nil {
+			return
+		}
+	}
+	msg.SessionID = session.id
+
+	if !msg.IsRequest() {
+		if err := t.reader.push(msg, r.Context(), t.done); err != nil {
+			t.logger.Error("Failed to queue message: %v", err)
+			http.Error(w, "Transport unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	useEventStream := !t.config.JSONResponse && acceptsEventStream(r)
+	stream, ok := session.openRequestStream(msg.ID, useEventStream)
+	if !ok {
+		writeJSONRPCError(w, http.StatusConflict, messages.JsonRPCErrorInvalidRequest, "Invalid Request: a request with this id is already in flight")
+		return
+	}
+	defer session.closeRequestStream(msg.ID, stream)
+
+	if err := t.reader.push(msg, r.Context(), t.done); err != nil {
+		t.logger.Error("Failed to queue request: %v", err)
+		http.Error(w, "Transport unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	t.logger.Debug("Queued request message: method=%s, id=%v, session=%s", *msg.Method, msg.ID, session.id)
+
+	if useEventStream {
+		serveEventStream(w, r, stream, t.done, t.logger, true)
+		return
+	}
+
+	select {
+	case response := <-stream.messages:
+		writeJSONMessage(w, http.StatusOK, response)
+	case <-r.Context().Done():
+		t.logger.Warn("Client disconnected before response to request id=%v", msg.ID)
+	case <-stream.done:
+		http.Error(w, "Session closed", http.StatusNotFound)
+	case <-t.done:
+		http.Error(w, "Transport stopped", http.StatusServiceUnavailable)
+	}
+}
+
+func (t *StreamableHTTPTransport) handlePostBatch(w http.ResponseWriter, r *http.Request, body []byte) {
+	batch, ok := decodeBatchBody(w, body, t.logger)
+	if !ok {
+		return
+	}
+
+	needsReply := false
+	for _, msg := range batch.Messages {
+		if msg.IsRequest() && *msg.Method == "initialize" {
+			writeJSONRPCError(w, http.StatusBadRequest, messages.JsonRPCErrorInvalidRequest, "Invalid Request: initialize must not be part of a batch")
+			return
+		}
+		if !msg.IsNotification() && !msg.IsResponse() {
+			needsReply = true
+		}
+	}
+
+	session := t.sessionFromRequest(w, r)
+	if session == nil {
+		return
+	}
+	batch.SessionID = session.id
+	for i := range batch.Messages {
+		batch.Messages[i].SessionID = session.id
+	}
+
+	if !needsReply {
+		if err := t.batchReader.push(batch, r.Context(), t.done); err != nil {
+			t.logger.Error("Failed to queue batch: %v", err)
+			http.Error(w, "Transport unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	tag := batchTag(t.nextBatchTag.Add(1))
+	batch.Tag = tag
+
+	useEventStream := !t.config.JSONResponse && acceptsEventStream(r)
+	// Batch tags are never reused, so the stream always opens
+	stream, _ := session.openRequestStream(tag, useEventStream)
+	defer session.closeRequestStream(tag, stream)
+
+	if err := t.batchReader.push(batch, r.Context(), t.done); err != nil {
+		t.logger.Error("Failed to queue batch: %v", err)
+		http.Error(w, "Transport unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	t.logger.Debug("Queued batch: %d messages, session=%s", len(batch.Messages), session.id)
+
+	if useEventStream {
+		serveEventStream(w, r, stream, t.done, t.logger, true)
+		return
+	}
+
+	select {
+	case reply := <-stream.batches:
+		writeJSONMessage(w, http.StatusOK, reply)
+	case <-r.Context().Done():
+		t.logger.Warn("Client disconnected before batch response")
+	case <-stream.done:
+		http.Error(w, "Session closed", http.StatusNotFound)
+	case <-t.done:
+		http.Error(w, "Transport stopped", http.StatusServiceUnavailable)
+	}
+}
+
+func (t *StreamableHTTPTransport) handleGet(w http.ResponseWriter, r *http.Request) {
+	if !acceptsEventStream(r) {
+		http.Error(w, "Accept header must include text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+
+	session := t.sessionFromRequest(w, r)
+	if session == nil {
+		return
+	}
+
+	stream := newHTTPStream()
+	session.mutex.Lock()
+	if session.standaloneStream != nil {
+		session.mutex.Unlock()
+		http.Error(w, "Stream already open for this session", http.StatusConflict)
+		return
+	}
+	session.standaloneStream = stream
+	session.mutex.Unlock()
+
+	defer func() {
+		session.mutex.Lock()
+		if session.standaloneStream == stream {
+			session.standaloneStream = nil
+		}
+		session.lastActive = time.Now()
+		session.mutex.Unlock()
+		stream.close()
+	}()
+
+	serveEventStream(w, r, stream, t.done, t.logger, false)
+}
+
+func (t *StreamableHTTPTransport) handleDelete(w http.ResponseWriter, r *http.Request) {
+	session := t.sessionFromRequest(w, r)
+	if session == nil {
+		return
+	}
+
+	t.CloseSession(session.id)
+	w.WriteHeader(http.StatusNoContent)
+}
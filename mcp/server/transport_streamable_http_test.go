@@ -0,0 +1,532 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alwint3r/mcp2go/mcp/messages"
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+func newTestToolManager() *server.ToolManager {
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "echo",
+		Description: "Echo the given text",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"text": map[string]interface{}{
+					"type": "string",
+				},
+			},
+		},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		text, _ := arguments["text"].(string)
+		return server.ToolResult{
+			Content: []server.ToolCallContent{{Type: "text", Text: &text}},
+		}
+	})
+	toolManager.AddTool(server.Tool{
+		Name:        "progress",
+		Description: "Report progress once before answering",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		server.ProgressFromContext(ctx).Report(ctx, 1, 2, "halfway")
+		text := "done"
+		return server.ToolResult{
+			Content: []server.ToolCallContent{{Type: "text", Text: &text}},
+		}
+	})
+	return &toolManager
+}
+
+func startTestServer(t *testing.T, transport server.Transport) *server.DefaultServer {
+	t.Helper()
+
+	config := server.NewDefaultConfig()
+	config.LogLevel = server.LogError
+	s := server.NewDefaultServerWithConfig(transport, server.ProtocolVersion20250326, "1.0.0", "TestServer", config)
+	s = server.WithToolsCapability(s, false, false)
+	s = server.WithToolManager(s, newTestToolManager())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go transport.Start(ctx)
+	go s.Start(ctx)
+
+	t.Cleanup(func() {
+		s.Close()
+		cancel()
+	})
+	return s
+}
+
+func postMessage(t *testing.T, url string, sessionID string, accept string, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", accept)
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to post message: %v", err)
+	}
+	return resp
+}
+
+func decodeJSONResponse(t *testing.T, resp *http.Response) messages.JsonRPCMessage {
+	t.Helper()
+	defer resp.Body.Close()
+
+	var msg messages.JsonRPCMessage
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return msg
+}
+
+func readEventStreamMessage(t *testing.T, reader *bufio.Reader) messages.JsonRPCMessage {
+	t.Helper()
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read event stream: %v", err)
+		}
+		if data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: "); ok {
+			var msg messages.JsonRPCMessage
+			if err := json.Unmarshal([]byte(data), &msg); err != nil {
+				t.Fatalf("failed to decode event data: %v", err)
+			}
+			return msg
+		}
+	}
+}
+
+const initializeBody = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.0.0"}}}`
+
+func TestStreamableHTTPJSONResponse(t *testing.T) {
+	config := server.NewDefaultStreamableHTTPConfig()
+	config.JSONResponse = true
+	transport := server.NewStreamableHTTPTransportWithConfig(config)
+	startTestServer(t, transport)
+
+	httpServer := httptest.NewServer(transport)
+	defer httpServer.Close()
+
+	resp := postMessage(t, httpServer.URL, "", "application/json, text/event-stream", initializeBody)
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	if sessionID == "" {
+		t.Fatalf("initialize response should carry a session id")
+	}
+	initResult := decodeJSONResponse(t, resp)
+	if initResult.Result == nil || (*initResult.Result)["protocolVersion"] != server.ProtocolVersion20250326 {
+		t.Errorf("unexpected initialize result: %+v", initResult)
+	}
+
+	resp = postMessage(t, httpServer.URL, sessionID, "application/json, text/event-stream", `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("notification should be accepted, got status %d", resp.StatusCode)
+	}
+
+	resp = postMessage(t, httpServer.URL, sessionID, "application/json, text/event-stream", `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hello"}}}`)
+	callResult := decodeJSONResponse(t, resp)
+	if callResult.ID != float64(2) {
+		t.Errorf("response ID should be 2, got %v", callResult.ID)
+	}
+	content := (*callResult.Result)["content"].([]interface{})
+	if text := content[0].(map[string]interface{})["text"]; text != "hello" {
+		t.Errorf("tool should echo hello, got %v", text)
+	}
+
+	resp = postMessage(t, httpServer.URL, "", "application/json", `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("request without session should be rejected with 400, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, httpServer.URL, nil)
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	deleteResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to delete session: %v", err)
+	}
+	deleteResp.Body.Close()
+
+	resp = postMessage(t, httpServer.URL, sessionID, "application/json", `{"jsonrpc":"2.0","id":4,"method":"tools/list"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("request to a deleted session should be rejected with 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestStreamableHTTPEventStreamResponse(t *testing.T) {
+	transport := server.NewStreamableHTTPTransport()
+	startTestServer(t, transport)
+
+	httpServer := httptest.NewServer(transport)
+	defer httpServer.Close()
+
+	resp := postMessage(t, httpServer.URL, "", "application/json, text/event-stream", initializeBody)
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("response should be an event stream, got %s", contentType)
+	}
+
+	msg := readEventStreamMessage(t, bufio.NewReader(resp.Body))
+	if !msg.IsResponse() || msg.ID != float64(1) {
+		t.Errorf("expected initialize response, got %+v", msg)
+	}
+
+	if rest, _ := io.ReadAll(resp.Body); strings.TrimSpace(string(rest)) != "" {
+		t.Errorf("stream should end after the response, got %q", rest)
+	}
+}
+
+func TestStreamableHTTPRelatedMessages(t *testing.T) {
+	transport := server.NewStreamableHTTPTransport()
+	s := startTestServer(t, transport)
+
+	httpServer := httptest.NewServer(transport)
+	defer httpServer.Close()
+
+	resp := postMessage(t, httpServer.URL, "", "application/json, text/event-stream", initializeBody)
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	readEventStreamMessage(t, bufio.NewReader(resp.Body))
+	resp.Body.Close()
+	postMessage(t, httpServer.URL, sessionID, "application/json", `{"jsonrpc":"2.0","method":"notifications/initialized"}`).Body.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, httpServer.URL, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	getResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to open GET stream: %v", err)
+	}
+	defer getResp.Body.Close()
+	getStream := bufio.NewReader(getResp.Body)
+
+	// Progress belongs to the call and travels on its POST stream ahead of the response, not on the GET stream
+	resp = postMessage(t, httpServer.URL, sessionID, "application/json, text/event-stream", `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"progress","arguments":{},"_meta":{"progressToken":"p"}}}`)
+	defer resp.Body.Close()
+	postStream := bufio.NewReader(resp.Body)
+	progress := readEventStreamMessage(t, postStream)
+	if progress.Method == nil || *progress.Method != "notifications/progress" || (*progress.Params)["progressToken"] != "p" {
+		t.Fatalf("expected progress on the POST stream, got %+v", progress)
+	}
+	if response := readEventStreamMessage(t, postStream); !response.IsResponse() || response.ID != float64(2) {
+		t.Errorf("expected the call's response after its progress, got %+v", response)
+	}
+
+	// Messages unrelated to a request go to the GET stream
+	pingErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_, err := s.SendRequestToSession(ctx, sessionID, "ping", nil)
+		pingErr <- err
+	}()
+	ping := readEventStreamMessage(t, getStream)
+	if ping.Method == nil || *ping.Method != "ping" {
+		t.Fatalf("expected only the server's ping on the GET stream, got %+v", ping)
+	}
+	pingResp := postMessage(t, httpServer.URL, sessionID, "application/json", fmt.Sprintf(`{"jsonrpc":"2.0","id":%v,"result":{}}`, ping.ID))
+	pingResp.Body.Close()
+	if err := <-pingErr; err != nil {
+		t.Errorf("expected the ping to be answered, got %v", err)
+	}
+}
+
+func TestStreamableHTTPRejectsDuplicateRequestID(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	toolManager := server.NewToolManager()
+	toolManager.AddTool(server.Tool{
+		Name:        "wait",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, name string, arguments map[string]interface{}) server.ToolResult {
+		started <- struct{}{}
+		<-release
+		text := "released"
+		return server.ToolResult{Content: []server.ToolCallContent{{Type: "text", Text: &text}}}
+	})
+
+	config := server.NewDefaultStreamableHTTPConfig()
+	config.JSONResponse = true
+	transport := server.NewStreamableHTTPTransportWithConfig(config)
+	s := startTestServer(t, transport)
+	server.WithToolManager(s, &toolManager)
+
+	httpServer := httptest.NewServer(transport)
+	defer httpServer.Close()
+
+	resp := postMessage(t, httpServer.URL, "", "application/json", initializeBody)
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	resp.Body.Close()
+	postMessage(t, httpServer.URL, sessionID, "application/json", `{"jsonrpc":"2.0","method":"notifications/initialized"}`).Body.Close()
+
+	first := make(chan messages.JsonRPCMessage, 1)
+	go func() {
+		resp := postMessage(t, httpServer.URL, sessionID, "application/json", `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"wait","arguments":{}}}`)
+		first <- decodeJSONResponse(t, resp)
+	}()
+	<-started
+
+	resp = postMessage(t, httpServer.URL, sessionID, "application/json", `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected a duplicate in-flight ID to be rejected with 409, got %d", resp.StatusCode)
+	}
+	if response := decodeJSONResponse(t, resp); response.Error == nil || response.Error.Code != messages.JsonRPCErrorInvalidRequest {
+		t.Errorf("expected invalid request error, got %+v", response)
+	}
+
+	close(release)
+	select {
+	case response := <-first:
+		if response.Result == nil || (*response.Result)["isError"] != false {
+			t.Errorf("expected the first request to keep its stream, got %+v", response)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the first request's response")
+	}
+}
+
+func TestStreamableHTTPDeleteRemovesServerSession(t *testing.T) {
+	transport := server.NewStreamableHTTPTransport()
+	s := startTestServer(t, transport)
+
+	httpServer := httptest.NewServer(transport)
+	defer httpServer.Close()
+
+	resp := postMessage(t, httpServer.URL, "", "application/json", initializeBody)
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	resp.Body.Close()
+	if sessions := s.Sessions(); len(sessions) != 1 || sessions[0].ID != sessionID {
+		t.Fatalf("expected the server to hold session %s, got %+v", sessionID, sessions)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, httpServer.URL, nil)
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	deleteResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to delete session: %v", err)
+	}
+	deleteResp.Body.Close()
+
+	if sessions := s.Sessions(); len(sessions) != 0 {
+		t.Errorf("expected deleted session to be removed from the server, got %+v", sessions)
+	}
+
+	// Sending to the deleted session must not bring it back
+	if _, err := s.SendRequestToSession(context.Background(), sessionID, "roots/list", nil); !errors.Is(err, server.ErrSessionNotFound) {
+		t.Errorf("expected session not found for the deleted session, got %v", err)
+	}
+	if sessions := s.Sessions(); len(sessions) != 0 {
+		t.Errorf("expected no session to be recreated, got %+v", sessions)
+	}
+}
+
+func TestStreamableHTTPSessionCleanup(t *testing.T) {
+	config := server.NewDefaultStreamableHTTPConfig()
+	config.JSONResponse = true
+	config.SessionIdleTimeoutSeconds = 1
+	transport := server.NewStreamableHTTPTransportWithConfig(config)
+	s := startTestServer(t, transport)
+
+	httpServer := httptest.NewServer(transport)
+	defer httpServer.Close()
+
+	resp := postMessage(t, httpServer.URL, "", "application/json", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	failedSessionID := resp.Header.Get("Mcp-Session-Id")
+	if response := decodeJSONResponse(t, resp); response.Error == nil {
+		t.Fatalf("expected initialize without params to fail, got %+v", response)
+	}
+	resp = postMessage(t, httpServer.URL, failedSessionID, "application/json", `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected the session of a failed initialize to be removed, got status %d", resp.StatusCode)
+	}
+	if sessions := s.Sessions(); len(sessions) != 0 {
+		t.Errorf("expected the server to drop the failed session, got %+v", sessions)
+	}
+
+	resp = postMessage(t, httpServer.URL, "", "application/json", initializeBody)
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	resp.Body.Close()
+	deadline := time.Now().Add(3 * time.Second)
+	for len(s.Sessions()) != 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if sessions := s.Sessions(); len(sessions) != 0 {
+		t.Errorf("expected the idle session to be closed, got %+v", sessions)
+	}
+	resp = postMessage(t, httpServer.URL, sessionID, "application/json", `{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected the idle session to be gone, got status %d", resp.StatusCode)
+	}
+}
+
+func TestStreamableHTTPInvalidRequests(t *testing.T) {
+	transport := server.NewStreamableHTTPTransportWithConfig(server.StreamableHTTPConfig{
+		AllowedOrigins: []string{"http://localhost"},
+	})
+	startTestServer(t, transport)
+
+	httpServer := httptest.NewServer(transport)
+	defer httpServer.Close()
+
+	resp := postMessage(t, httpServer.URL, "", "application/json", `{"jsonrpc":`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("malformed body should be rejected with 400, got %d", resp.StatusCode)
+	}
+	parseError := decodeJSONResponse(t, resp)
+	if parseError.Error == nil || parseError.Error.Code != messages.JsonRPCErrorParse {
+		t.Errorf("expected parse error, got %+v", parseError)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, httpServer.URL, strings.NewReader(initializeBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "http://evil.example")
+	originResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to post message: %v", err)
+	}
+	originResp.Body.Close()
+	if originResp.StatusCode != http.StatusForbidden {
+		t.Errorf("foreign origin should be rejected with 403, got %d", originResp.StatusCode)
+	}
+
+	putReq, _ := http.NewRequest(http.MethodPut, httpServer.URL, nil)
+	putResp, err := http.DefaultClient.Do(putReq)
+	if err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	putResp.Body.Close()
+	if putResp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("unsupported method should be rejected with 405, got %d", putResp.StatusCode)
+	}
+}
+
+func postWithOrigin(t *testing.T, url string, origin string) int {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(initializeBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("Origin", origin)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to post message: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestStreamableHTTPDefaultOrigins(t *testing.T) {
+	transport := server.NewStreamableHTTPTransport()
+	startTestServer(t, transport)
+
+	httpServer := httptest.NewServer(transport)
+	defer httpServer.Close()
+
+	if status := postWithOrigin(t, httpServer.URL, "http://evil.example"); status != http.StatusForbidden {
+		t.Errorf("foreign origin should be rejected with 403 by default, got %d", status)
+	}
+	for _, origin := range []string{"http://localhost:3000", "http://127.0.0.1:8080", "http://[::1]"} {
+		if status := postWithOrigin(t, httpServer.URL, origin); status != http.StatusOK {
+			t.Errorf("loopback origin %s should be allowed by default, got %d", origin, status)
+		}
+	}
+
+	config := server.NewDefaultStreamableHTTPConfig()
+	config.AllowedOrigins = []string{"*"}
+	openTransport := server.NewStreamableHTTPTransportWithConfig(config)
+	startTestServer(t, openTransport)
+
+	openServer := httptest.NewServer(openTransport)
+	defer openServer.Close()
+
+	if status := postWithOrigin(t, openServer.URL, "http://evil.example"); status != http.StatusOK {
+		t.Errorf("every origin should be allowed after opting in, got %d", status)
+	}
+}
+
+func TestStreamableHTTPBatch(t *testing.T) {
+	config := server.NewDefaultStreamableHTTPConfig()
+	config.JSONResponse = true
+	transport := server.NewStreamableHTTPTransportWithConfig(config)
+	startTestServer(t, transport)
+
+	httpServer := httptest.NewServer(transport)
+	defer httpServer.Close()
+
+	resp := postMessage(t, httpServer.URL, "", "application/json", initializeBody)
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	resp.Body.Close()
+
+	notificationsResp := postMessage(t, httpServer.URL, sessionID, "application/json", `[{"jsonrpc":"2.0","method":"notifications/initialized"}]`)
+	notificationsResp.Body.Close()
+	if notificationsResp.StatusCode != http.StatusAccepted {
+		t.Errorf("batch of notifications should be accepted, got status %d", notificationsResp.StatusCode)
+	}
+
+	batchBody := `[
+		{"jsonrpc":"2.0","id":2,"method":"tools/list"},
+		{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":99}},
+		1,
+		{"jsonrpc":"2.0","id":3,"method":"unknown/method"},
+		{"jsonrpc":"2.0","id":{"x":1},"method":"ping"}
+	]`
+	resp = postMessage(t, httpServer.URL, sessionID, "application/json", batchBody)
+	defer resp.Body.Close()
+
+	var replies []messages.JsonRPCMessage
+	if err := json.NewDecoder(resp.Body).Decode(&replies); err != nil {
+		t.Fatalf("failed to decode batch response: %v", err)
+	}
+	if len(replies) != 4 {
+		t.Fatalf("expected 4 responses without the notification, got %d", len(replies))
+	}
+	if replies[0].ID != float64(2) || replies[0].Result == nil {
+		t.Errorf("expected tools/list result first, got %+v", replies[0])
+	}
+	if replies[1].ID != nil || replies[1].Error == nil || replies[1].Error.Code != messages.JsonRPCErrorInvalidRequest {
+		t.Errorf("expected invalid request error with null id, got %+v", replies[1])
+	}
+	if replies[2].ID != float64(3) || replies[2].Error == nil || replies[2].Error.Code != messages.JsonRPCErrorMethodNotFound {
+		t.Errorf("expected method not found error, got %+v", replies[2])
+	}
+	if replies[3].ID != nil || replies[3].Error == nil || replies[3].Error.Code != messages.JsonRPCErrorInvalidRequest {
+		t.Errorf("expected invalid request error for an object id, got %+v", replies[3])
+	}
+
+	objectIDResp := postMessage(t, httpServer.URL, sessionID, "application/json", `{"jsonrpc":"2.0","id":{"x":1},"method":"ping"}`)
+	objectIDError := decodeJSONResponse(t, objectIDResp)
+	if objectIDError.Error == nil || objectIDError.Error.Code != messages.JsonRPCErrorInvalidRequest {
+		t.Errorf("object id should be an invalid request, got %+v", objectIDError)
+	}
+
+	emptyResp := postMessage(t, httpServer.URL, sessionID, "application/json", `[]`)
+	emptyError := decodeJSONResponse(t, emptyResp)
+	if emptyError.Error == nil || emptyError.Error.Code != messages.JsonRPCErrorInvalidRequest {
+		t.Errorf("empty batch should be an invalid request, got %+v", emptyError)
+	}
+}
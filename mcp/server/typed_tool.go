@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+var (
+	toolResultType      = reflect.TypeOf(ToolResult{})
+	toolCallContentType = reflect.TypeOf([]ToolCallContent{})
+)
+
+// typedOutputSchema reflects the output schema of struct outputs, other outputs are returned as content without one
+func typedOutputSchema(outputType reflect.Type) (map[string]interface{}, error) {
+	for outputType.Kind() == reflect.Pointer {
+		outputType = outputType.Elem()
+	}
+	if outputType == toolResultType || outputType == toolCallContentType || outputType.Kind() == reflect.String {
+		return nil, nil
+	}
+
+	schema, err := ReflectSchema(outputType)
+	if err != nil || schema["type"] != "object" {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// AddTypedTool registers a tool whose input schema is reflected from In and whose arguments are decoded into In before fn runs,
+// struct outputs are declared as the output schema and returned as structured content
+func AddTypedTool[In, Out any](tm *ToolManager, name string, description string, fn func(context.Context, In) (Out, error)) error {
+	inputType := reflect.TypeOf((*In)(nil)).Elem()
+	schema, err := ReflectSchema(inputType)
+	if err != nil {
+		return fmt.Errorf("failed to reflect input schema of tool %s: %w", name, err)
+	}
+	if schema["type"] != "object" {
+		return fmt.Errorf("input of tool %s must be a struct, got %s", name, inputType)
+	}
+
+	outputSchema, err := typedOutputSchema(reflect.TypeOf((*Out)(nil)).Elem())
+	if err != nil {
+		return fmt.Errorf("failed to reflect output schema of tool %s: %w", name, err)
+	}
+
+	tm.AddTool(Tool{
+		Name:         name,
+		Description:  description,
+		InputSchema:  schema,
+		OutputSchema: outputSchema,
+	}, func(ctx context.Context, toolName string, arguments map[string]interface{}) ToolResult {
+		var input In
+		if err := decodeToolArguments(schema, arguments, &input); err != nil {
+			return newToolErrorResult(err)
+		}
+
+		output, err := fn(ctx, input)
+		if err != nil {
+			return newToolErrorResult(err)
+		}
+
+		if outputSchema != nil {
+			return ToolResult{StructuredContent: output}
+		}
+
+		result, err := toolResultFromOutput(output)
+		if err != nil {
+			return newToolErrorResult(err)
+		}
+		return result
+	})
+
+	return nil
+}
+
+// decodeToolArguments fills in schema defaults and decodes the already validated arguments into target
+func decodeToolArguments(schema map[string]interface{}, arguments map[string]interface{}, target interface{}) error {
+	withDefaults := make(map[string]interface{}, len(arguments))
+	for key, value := range arguments {
+		withDefaults[key] = value
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, property := range properties {
+		definition, _ := property.(map[string]interface{})
+		if defaultValue, exist := definition["default"]; exist {
+			if _, present := withDefaults[name]; !present {
+				withDefaults[name] = defaultValue
+			}
+		}
+	}
+
+	if err := remarshal(withDefaults, target); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// toolResultFromOutput passes results and content through, strings become text and anything else is serialized as JSON text
+func toolResultFromOutput(output interface{}) (ToolResult, error) {
+	switch value := output.(type) {
+	case ToolResult:
+		return value, nil
+	case *ToolResult:
+		if value != nil {
+			return *value, nil
+		}
+	case []ToolCallContent:
+		return ToolResult{Content: value}, nil
+	case string:
+		return ToolResult{Content: []ToolCallContent{TextContent(value)}}, nil
+	}
+
+	marshaled, err := json.Marshal(output)
+	if err != nil {
+		return ToolResult{}, fmt.Errorf("failed to marshal tool output: %w", err)
+	}
+	return ToolResult{Content: []ToolCallContent{TextContent(string(marshaled))}}, nil
+}
@@ -0,0 +1,113 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+type typedAddress struct {
+	City string `json:"city" required:"true"`
+}
+
+type typedInput struct {
+	Location string            `json:"location" required:"true" description:"City name" min:"2"`
+	Units    string            `json:"units,omitempty" enum:"metric,imperial" default:"metric"`
+	Days     int               `json:"days" min:"1" max:"7" default:"3"`
+	Tags     []string          `json:"tags,omitempty"`
+	Address  *typedAddress     `json:"address,omitempty"`
+	Extra    map[string]string `json:"extra,omitempty"`
+	internal string
+}
+
+type typedOutput struct {
+	Summary string `json:"summary"`
+}
+
+func TestReflectSchema(t *testing.T) {
+	schema, err := server.ReflectSchema(reflect.TypeOf(typedInput{}))
+	if err != nil {
+		t.Fatalf("failed to reflect schema: %v", err)
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	if len(properties) != 6 {
+		t.Errorf("expected 6 properties, got %v", properties)
+	}
+	if required := schema["required"]; !reflect.DeepEqual(required, []string{"location"}) {
+		t.Errorf("unexpected required list %v", required)
+	}
+
+	location := properties["location"].(map[string]interface{})
+	if location["type"] != "string" || location["description"] != "City name" || location["minLength"] != float64(2) {
+		t.Errorf("unexpected location schema %v", location)
+	}
+	units := properties["units"].(map[string]interface{})
+	if !reflect.DeepEqual(units["enum"], []interface{}{"metric", "imperial"}) || units["default"] != "metric" {
+		t.Errorf("unexpected units schema %v", units)
+	}
+	days := properties["days"].(map[string]interface{})
+	if days["type"] != "integer" || days["minimum"] != float64(1) || days["maximum"] != float64(7) || days["default"] != int64(3) {
+		t.Errorf("unexpected days schema %v", days)
+	}
+	address := properties["address"].(map[string]interface{})
+	if address["type"] != "object" || !reflect.DeepEqual(address["required"], []string{"city"}) {
+		t.Errorf("unexpected address schema %v", address)
+	}
+
+	if _, err := server.ReflectSchema(reflect.TypeOf(make(chan int))); err == nil {
+		t.Errorf("expected channels to be unsupported")
+	}
+}
+
+func TestAddTypedTool(t *testing.T) {
+	toolManager := server.NewToolManager()
+	err := server.AddTypedTool(&toolManager, "forecast", "Weather forecast", func(ctx context.Context, input typedInput) (typedOutput, error) {
+		if input.Location == "nowhere" {
+			return typedOutput{}, errors.New("unknown location")
+		}
+		return typedOutput{Summary: input.Location + " " + input.Units + " " + strings.Repeat("*", input.Days)}, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to add typed tool: %v", err)
+	}
+	if err := server.AddTypedTool(&toolManager, "bad", "", func(ctx context.Context, input string) (string, error) { return input, nil }); err == nil {
+		t.Errorf("expected non-struct input to be refused")
+	}
+
+	cases := []struct {
+		arguments map[string]interface{}
+		text      string
+		isError   bool
+	}{
+		{map[string]interface{}{"location": "Bandung"}, `{"summary":"Bandung metric ***"}`, false},
+		{map[string]interface{}{"location": "Oslo", "units": "imperial", "days": 1}, `{"summary":"Oslo imperial *"}`, false},
+		{map[string]interface{}{}, "invalid arguments for tool forecast: schema validation failed: /location: is required", true},
+		{map[string]interface{}{"location": "Oslo", "days": 9}, "invalid arguments for tool forecast: schema validation failed: /days: must be at most 7", true},
+		{map[string]interface{}{"location": "nowhere"}, "unknown location", true},
+	}
+	tools := *toolManager.ListAllTools()
+	if outputSchema := tools[0].OutputSchema; outputSchema == nil || outputSchema["properties"].(map[string]interface{})["summary"] == nil {
+		t.Errorf("expected output schema reflected from the output struct, got %v", outputSchema)
+	}
+	result := toolManager.CallTool(context.Background(), "forecast", map[string]interface{}{"location": "Bandung"})
+	if output, ok := result.StructuredContent.(typedOutput); !ok || output.Summary != "Bandung metric ***" {
+		t.Errorf("expected structured content, got %+v", result.StructuredContent)
+	}
+
+	result = toolManager.CallTool(context.Background(), "forecast", map[string]interface{}{"days": "many"})
+	if validationErr, ok := result.StructuredContent.(*server.SchemaValidationError); !ok || len(validationErr.Violations) != 2 {
+		t.Errorf("expected every violation as structured content, got %+v", result.StructuredContent)
+	}
+
+	for _, c := range cases {
+		result := toolManager.CallTool(context.Background(), "forecast", c.arguments)
+		if result.IsError != c.isError || !strings.HasPrefix(*result.Content[0].Text, c.text) {
+			t.Errorf("CallTool(%v) = %q (error %v), expected %q (error %v)", c.arguments, *result.Content[0].Text, result.IsError, c.text, c.isError)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type uriTemplateVariable struct {
+	name      string
+	maxLength int
+	explode   bool
+}
+
+type uriTemplateExpression struct {
+	operator  byte
+	variables []uriTemplateVariable
+}
+
+// URITemplate is an RFC 6570 URI template that can be matched against concrete URIs
+type URITemplate struct {
+	raw           string
+	expressions   []uriTemplateExpression
+	pattern       *regexp.Regexp
+	literalLength int
+}
+
+var uriTemplateVariableName = regexp.MustCompile(`^(?:[A-Za-z0-9_]|%[0-9A-Fa-f]{2})(?:\.?(?:[A-Za-z0-9_]|%[0-9A-Fa-f]{2}))*$`)
+
+func ParseURITemplate(template string) (*URITemplate, error) {
+	t := &URITemplate{raw: template}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+
+	rest := template
+	for len(rest) > 0 {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			if strings.IndexByte(rest, '}') >= 0 {
+				return nil, fmt.Errorf("invalid uri template %q: unmatched '}'", template)
+			}
+			pattern.WriteString(regexp.QuoteMeta(rest))
+			t.literalLength += len(rest)
+			break
+		}
+
+		literal := rest[:open]
+		if strings.IndexByte(literal, '}') >= 0 {
+			return nil, fmt.Errorf("invalid uri template %q: unmatched '}'", template)
+		}
+		pattern.WriteString(regexp.QuoteMeta(literal))
+		t.literalLength += len(literal)
+
+		closing := strings.IndexByte(rest[open:], '}')
+		if closing < 0 {
+			return nil, fmt.Errorf("invalid uri template %q: unterminated expression", template)
+		}
+
+		expression, err := parseURITemplateExpression(rest[open+1 : open+closing])
+		if err != nil {
+			return nil, fmt.Errorf("invalid uri template %q: %w", template, err)
+		}
+		t.expressions = append(t.expressions, expression)
+		pattern.WriteString(expression.pattern())
+
+		rest = rest[open+closing+1:]
+	}
+
+	pattern.WriteString("$")
+	compiled, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid uri template %q: %w", template, err)
+	}
+	t.pattern = compiled
+
+	return t, nil
+}
+
+func parseURITemplateExpression(body string) (uriTemplateExpression, error) {
+	expression := uriTemplateExpression{}
+	if body == "" {
+		return expression, fmt.Errorf("empty expression")
+	}
+
+	if strings.IndexByte("+#./;?&", body[0]) >= 0 {
+		expression.operator = body[0]
+		body = body[1:]
+	} else if strings.IndexByte("=,!@|", body[0]) >= 0 {
+		return expression, fmt.Errorf("reserved operator %q", body[0])
+	}
+
+	for _, spec := range strings.Split(body, ",") {
+		variable := uriTemplateVariable{name: spec}
+		if strings.HasSuffix(spec, "*") {
+			variable.name = strings.TrimSuffix(spec, "*")
+			variable.explode = true
+		} else if colon := strings.IndexByte(spec, ':'); colon >= 0 {
+			maxLength, err := strconv.Atoi(spec[colon+1:])
+			if err != nil || maxLength <= 0 || maxLength >= 10000 {
+				return expression, fmt.Errorf("invalid prefix modifier in %q", spec)
+			}
+			variable.name = spec[:colon]
+			variable.maxLength = maxLength
+		}
+
+		if !uriTemplateVariableName.MatchString(variable.name) {
+			return expression, fmt.Errorf("invalid variable name %q", variable.name)
+		}
+		expression.variables = append(expression.variables, variable)
+	}
+
+	return expression, nil
+}
+
+func (e uriTemplateExpression) prefix() string {
+	switch e.operator {
+	case '#', '.', '/', ';', '?', '&':
+		return string(e.operator)
+	default:
+		return ""
+	}
+}
+
+func (e uriTemplateExpression) separator() string {
+	switch e.operator {
+	case '.', '/', ';':
+		return string(e.operator)
+	case '?', '&':
+		return "&"
+	default:
+		return ","
+	}
+}
+
+func (e uriTemplateExpression) isExploded() bool {
+	for _, variable := range e.variables {
+		if variable.explode {
+			return true
+		}
+	}
+	return false
+}
+
+func (e uriTemplateExpression) isNamed() bool {
+	return e.operator == ';' || e.operator == '?' || e.operator == '&'
+}
+
+// valueClass returns the characters a single expanded value may contain
+func (e uriTemplateExpression) valueClass() string {
+	switch e.operator {
+	case '+':
+		if len(e.variables) == 1 {
+			return `[^?#]`
+		}
+		return `[^?#,]`
+	case '#':
+		if len(e.variables) == 1 {
+			return `.`
+		}
+		return `[^,]`
+	case '.':
+		return `[^/?#.]`
+	case '/':
+		return `[^/?#]`
+	default:
+		return `[^/?#,]`
+	}
+}
+
+// pattern captures each variable of unnamed expressions separately, named expressions
+// are captured as a whole and split into name=value pairs after matching
+func (e uriTemplateExpression) pattern() string {
+	prefix := regexp.QuoteMeta(e.prefix())
+	separator := regexp.QuoteMeta(e.separator())
+
+	if e.isNamed() {
+		value := `[^#&]*`
+		if e.operator == ';' {
+			value = `[^/?#;]*`
+		}
+
+		name := `[^#&;=/?]+`
+		if !e.isExploded() {
+			names := make([]string, 0, len(e.variables))
+			for _, variable := range e.variables {
+				names = append(names, regexp.QuoteMeta(variable.name))
+			}
+			name = "(?:" + strings.Join(names, "|") + ")"
+		}
+
+		pair := name + "(?:=" + value + ")?"
+		return "((?:" + prefix + pair + "(?:" + separator + pair + ")*)?)"
+	}
+
+	// Values are matched lazily so that optional expressions after them, like {.ext}, get their share,
+	// and they are never empty so that an empty segment like db://tables//rows does not bind ""
+	var groups strings.Builder
+	for i, variable := range e.variables {
+		value := e.valueClass() + "+?"
+		if variable.maxLength > 0 {
+			value = fmt.Sprintf("%s{1,%d}?", e.valueClass(), variable.maxLength)
+		} else if variable.explode {
+			value = fmt.Sprintf("%s+?(?:%s%s+?)*?", e.valueClass(), separator, e.valueClass())
+		}
+
+		if i == 0 {
+			groups.WriteString("(" + value + ")")
+		} else {
+			groups.WriteString("(?:" + separator + "(" + value + "))?")
+		}
+	}
+
+	if prefix == "" {
+		return groups.String()
+	}
+	return "(?:" + prefix + groups.String() + ")?"
+}
+
+func (t *URITemplate) String() string {
+	return t.raw
+}
+
+func (t *URITemplate) Variables() []string {
+	names := make([]string, 0)
+	for _, expression := range t.expressions {
+		for _, variable := range expression.variables {
+			names = append(names, variable.name)
+		}
+	}
+	return names
+}
+
+// Match reports whether uri is an expansion of the template and returns the extracted variables.
+// Variables outside query-style expressions only match non-empty values, a template like db://tables/{table}
+// does not match db://tables/, while {?q} accepts q= with an empty value.
+func (t *URITemplate) Match(uri string) (map[string]string, bool) {
+	submatches := t.pattern.FindStringSubmatchIndex(uri)
+	if submatches == nil {
+		return nil, false
+	}
+
+	variables := make(map[string]string)
+	group := 1
+	for _, expression := range t.expressions {
+		if expression.isNamed() {
+			start, end := submatches[2*group], submatches[2*group+1]
+			group++
+			if start < 0 || start == end {
+				continue
+			}
+			if !expression.matchNamed(uri[start:end], variables) {
+				return nil, false
+			}
+			continue
+		}
+
+		for _, variable := range expression.variables {
+			start, end := submatches[2*group], submatches[2*group+1]
+			group++
+			if start < 0 {
+				continue
+			}
+			value, err := url.PathUnescape(uri[start:end])
+			if err != nil {
+				return nil, false
+			}
+			variables[variable.name] = value
+		}
+	}
+
+	return variables, true
+}
+
+func (e uriTemplateExpression) matchNamed(section string, variables map[string]string) bool {
+	section = strings.TrimPrefix(section, e.prefix())
+	known := make(map[string]uriTemplateVariable)
+	for _, variable := range e.variables {
+		known[variable.name] = variable
+	}
+	exploded := e.isExploded()
+
+	for _, pair := range strings.Split(section, e.separator()) {
+		name, rawValue, _ := strings.Cut(pair, "=")
+		value, err := url.PathUnescape(rawValue)
+		if err != nil {
+			return false
+		}
+
+		variable, isKnown := known[name]
+		if !isKnown && !exploded {
+			return false
+		}
+		if isKnown && variable.maxLength > 0 && len([]rune(value)) > variable.maxLength {
+			return false
+		}
+		variables[name] = value
+	}
+	return true
+}
+
+// moreSpecificThan orders templates so that the one with the most literal characters wins, then the one with fewer variables
+func (t *URITemplate) moreSpecificThan(other *URITemplate) bool {
+	if t.literalLength != other.literalLength {
+		return t.literalLength > other.literalLength
+	}
+	return len(t.Variables()) < len(other.Variables())
+}
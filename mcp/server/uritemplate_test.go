@@ -0,0 +1,123 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/alwint3r/mcp2go/mcp/server"
+)
+
+func TestURITemplateMatch(t *testing.T) {
+	testCases := []struct {
+		template  string
+		uri       string
+		matches   bool
+		variables map[string]string
+	}{
+		{"db://tables/{table}/rows/{id}", "db://tables/users/rows/42", true, map[string]string{"table": "users", "id": "42"}},
+		{"db://tables/{table}/rows/{id}", "db://tables/users/rows/42/extra", false, nil},
+		{"db://tables/{table}", "db://tables/with%20space", true, map[string]string{"table": "with space"}},
+		{"file:///{+path}", "file:///home/user/notes.txt", true, map[string]string{"path": "home/user/notes.txt"}},
+		{"file:///{path}", "file:///home/user/notes.txt", false, nil},
+		{"repo://{owner}{/segments*}", "repo://gopher/a/b/c", true, map[string]string{"owner": "gopher", "segments": "a/b/c"}},
+		{"files://{name}{.ext}", "files://report.pdf", true, map[string]string{"name": "report", "ext": "pdf"}},
+		{"search://items{?q,limit}", "search://items?q=go&limit=10", true, map[string]string{"q": "go", "limit": "10"}},
+		{"search://items{?q,limit}", "search://items", true, map[string]string{}},
+		{"search://items{?q}{&page}", "search://items?q=go&page=2", true, map[string]string{"q": "go", "page": "2"}},
+		{"search://items{?q}", "search://items?other=1", false, nil},
+		{"doc://{id}{#section}", "doc://42#intro", true, map[string]string{"id": "42", "section": "intro"}},
+		{"map://{x,y}", "map://1024,768", true, map[string]string{"x": "1024", "y": "768"}},
+		{"code://{hash:4}", "code://abcd", true, map[string]string{"hash": "abcd"}},
+		{"code://{hash:4}", "code://abcdef", false, nil},
+		{"db://tables/{table}", "db://tables/", false, nil},
+		{"db://tables/{table}/rows/{id}", "db://tables//rows/42", false, nil},
+		{"map://{x,y}", "map://1024,", false, nil},
+		{"files://{name}{.ext}", "files://report", true, map[string]string{"name": "report"}},
+		{"search://items{?q}", "search://items?q=", true, map[string]string{"q": ""}},
+	}
+
+	for _, testCase := range testCases {
+		template, err := server.ParseURITemplate(testCase.template)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", testCase.template, err)
+		}
+
+		variables, matches := template.Match(testCase.uri)
+		if matches != testCase.matches {
+			t.Errorf("%s against %s: expected match=%v, got %v", testCase.template, testCase.uri, testCase.matches, matches)
+			continue
+		}
+		if matches && !reflect.DeepEqual(variables, testCase.variables) {
+			t.Errorf("%s against %s: expected %v, got %v", testCase.template, testCase.uri, testCase.variables, variables)
+		}
+	}
+}
+
+func TestURITemplateParseErrors(t *testing.T) {
+	invalidTemplates := []string{
+		"db://{table",
+		"db://table}",
+		"db://{}",
+		"db://{=table}",
+		"db://{ta ble}",
+		"db://{table:0}",
+	}
+
+	for _, template := range invalidTemplates {
+		if _, err := server.ParseURITemplate(template); err == nil {
+			t.Errorf("%s should fail to parse", template)
+		}
+	}
+}
+
+func TestResourceManagerTemplateSelection(t *testing.T) {
+	resourceManager := server.NewResourceManager()
+
+	register := func(uriTemplate string) {
+		err := resourceManager.AddResourceTemplate(server.ResourceTemplate{
+			URITemplate: uriTemplate,
+			Name:        uriTemplate,
+		}, func(ctx context.Context, uri string, variables map[string]string) ([]server.ResourceContents, error) {
+			return []server.ResourceContents{server.NewTextResourceContents(uri, "text/plain", uriTemplate)}, nil
+		})
+		if err != nil {
+			t.Fatalf("failed to add template %s: %v", uriTemplate, err)
+		}
+	}
+
+	register("db://{path}")
+	register("db://tables/{table}")
+	register("db://tables/{table}/rows/{id}")
+	register("db://tables/users")
+	resourceManager.AddResource(server.Resource{URI: "db://tables/orders", Name: "orders"}, func(ctx context.Context, uri string) ([]server.ResourceContents, error) {
+		return []server.ResourceContents{server.NewTextResourceContents(uri, "text/plain", "static")}, nil
+	})
+
+	expected := map[string]string{
+		"db://tables/users":         "db://tables/users",
+		"db://tables/products":      "db://tables/{table}",
+		"db://tables/users/rows/42": "db://tables/{table}/rows/{id}",
+		"db://schema":               "db://{path}",
+		"db://tables/orders":        "static",
+	}
+
+	for uri, text := range expected {
+		contents, err := resourceManager.ReadResource(context.Background(), uri)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", uri, err)
+		}
+		if *contents[0].Text != text {
+			t.Errorf("%s should be served by %s, got %s", uri, text, *contents[0].Text)
+		}
+	}
+
+	if _, err := resourceManager.ReadResource(context.Background(), "other://x"); !errors.Is(err, server.ErrResourceNotFound) {
+		t.Errorf("expected ErrResourceNotFound, got %v", err)
+	}
+
+	if templates := resourceManager.ListAllResourceTemplates(); len(*templates) != 4 || (*templates)[0].URITemplate != "db://{path}" {
+		t.Errorf("templates should be listed in registration order, got %+v", *templates)
+	}
+}